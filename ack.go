@@ -1,6 +1,8 @@
 package ravenworker
 
 import (
+	"errors"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v3"
@@ -19,9 +21,10 @@ func WithFilter() AckOptionFunc {
 	}
 }
 
-// WithFilter will keep the flow from processing further.
+// WithMessage will replace the content and metadata of the event.
 func WithMessage(message Message) AckOptionFunc {
 	return func(r *ackRequest) error {
+		r.Message = true
 		r.Content = message.Content
 		r.Metadata = message.MetaData
 		return nil
@@ -32,6 +35,7 @@ type ackRequest struct {
 	Content  []byte
 	Metadata []Metadata
 	Filter   bool
+	Message  bool // set by WithMessage.
 }
 
 type Metadata struct {
@@ -43,7 +47,9 @@ type Metadata struct {
 // allowed.
 //
 // WithFilter() will filter further processing
-func (c *DefaultWorker) Ack(ref Reference, options ...AckOptionFunc) error {
+//
+// The context will cancel the request and any retries.
+func (c *DefaultWorker) Ack(ctx context.Context, ref Reference, options ...AckOptionFunc) error {
 	// default ackRequest
 	ar := ackRequest{
 		Content:  nil,
@@ -58,42 +64,90 @@ func (c *DefaultWorker) Ack(ref Reference, options ...AckOptionFunc) error {
 		}
 	}
 
+	ackID, err := uuid.FromString(ref.AckID)
+	if err != nil {
+		return &Error{Kind: ErrInvalidReference, Err: err}
+	}
+
 	var t *time.Timer
 
 	cb := c.newBackOff()
 
-	for {
-		err := c.ack(ref, ar)
+	log := c.log.With(Field{"event_id", ref.EventID}, Field{"ack_id", ref.AckID})
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+
+		sctx, span := c.startSpan(ctx, "raven.ack")
+		span.set("event_id", ref.EventID)
+
+		req := ar
+		if ar.Message {
+			// the event continues the trace.
+			req.Metadata = span.inject(ar.Metadata)
+		}
+
+		err := c.ack(sctx, ackID, req)
+		c.metrics.ack.done(start, err)
+		span.end(err)
+
 		if err == nil {
+			atomic.AddInt64(&c.counters.acked, 1)
+			c.counters.activity()
+
+			if ar.Filter {
+				atomic.AddInt64(&c.metrics.filtered, 1)
+			}
+
+			c.metrics.ack.message(ar.Content)
 			return nil
 		}
 
+		// the server will not change its mind.
+		if errors.Is(err, ErrAckRejected) || permanent(err) {
+			log.Errorw("Could not ack message", "error", err, "attempt", attempt)
+			return err
+		}
+
 		next := cb.NextBackOff()
 		if next == backoff.Stop {
-			c.log.Errorf("Could not ack message: %s", err)
+			log.Errorw("Could not ack message", "error", err, "attempt", attempt)
 			return err
-		} else if t != nil {
+		}
+
+		c.metrics.ack.retry(next)
+
+		if t != nil {
 			t.Reset(next)
 		} else {
 			t = time.NewTimer(next)
 			defer t.Stop()
 		}
 
-		c.log.Debugf("Got error while ack message: %s. Will retry in %v.", err.Error(), next)
+		log.Debugw("Got error while ack message, will retry", "error", err, "attempt", attempt, "retry_in", next)
 
-		<-t.C
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
 	}
 }
 
-func (c *DefaultWorker) ack(ref Reference, ar ackRequest) error {
-	ackID, _ := uuid.FromString(ref.AckID)
+func (c *DefaultWorker) ack(ctx context.Context, ackID uuid.UUID, ar ackRequest) error {
+	conn, err := c.connection(ctx)
+	if err != nil {
+		return err
+	}
 
-	_, err := c.w.AckJob(context.Background(), func(params workflow.Connection_ackJob_Params) error {
+	res, err := conn.AckJob(ctx, func(params workflow.Connection_ackJob_Params) error {
 		e, err := params.NewEvent()
 		if err != nil {
 			return err
 		}
 
+		e.SetFilter(ar.Filter)
+
 		if err := e.SetContent([]byte(ar.Content)); err != nil {
 			return err
 		}
@@ -122,5 +176,13 @@ func (c *DefaultWorker) ack(ref Reference, ar ackRequest) error {
 		return nil
 	}).Struct()
 
-	return err
+	if err != nil {
+		return c.rpcError(err)
+	}
+
+	if !res.Acked() {
+		return &Error{Kind: ErrAckRejected}
+	}
+
+	return nil
 }
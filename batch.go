@@ -0,0 +1,121 @@
+package ravenworker
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/dutchsec/raven-worker/workflow"
+)
+
+// ProduceResult is the result of a single message of ProduceBatch.
+type ProduceResult struct {
+	EventID EventID
+	Err     error
+}
+
+// ProduceBatch will store the messages in the queue. The messages are
+// sent pipelined, without waiting for each result. Messages that could
+// not be stored will be retried using the backoff strategy.
+//
+//     results, err := w.ProduceBatch(ctx, messages)
+//     if err != nil {
+//         // results[i].Err tells which messages failed
+//     }
+//
+// The results are in the same order as messages. The returned error is
+// the error of the first message that failed.
+func (c *DefaultWorker) ProduceBatch(ctx context.Context, messages []Message) ([]ProduceResult, error) {
+	results := make([]ProduceResult, len(messages))
+
+	pending := make([]int, len(messages))
+	for i := range pending {
+		pending[i] = i
+	}
+
+	var t *time.Timer
+
+	cb := c.newBackOff()
+
+	for attempt := 1; ; attempt++ {
+		pending = c.produceBatch(ctx, messages, results, pending)
+		if len(pending) == 0 {
+			return results, nil
+		}
+
+		err := results[pending[0]].Err
+
+		next := cb.NextBackOff()
+		if permanent(err) {
+			return results, err
+		} else if next == backoff.Stop {
+			c.log.Errorw("Could not produce messages", "error", err, "failed", len(pending), "messages", len(messages), "attempt", attempt)
+			return results, err
+		}
+
+		c.metrics.produce.retry(next)
+
+		if t != nil {
+			t.Reset(next)
+		} else {
+			t = time.NewTimer(next)
+			defer t.Stop()
+		}
+
+		c.log.Debugw("Got error while producing messages, will retry", "error", err, "failed", len(pending), "attempt", attempt, "retry_in", next)
+
+		select {
+		case <-ctx.Done():
+			for _, i := range pending {
+				results[i].Err = ctx.Err()
+			}
+			return results, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// produceBatch sends the pending messages pipelined, stores the
+// results and returns the messages that failed.
+func (c *DefaultWorker) produceBatch(ctx context.Context, messages []Message, results []ProduceResult, pending []int) []int {
+	start := time.Now()
+
+	conn, err := c.connection(ctx)
+	if err != nil {
+		for _, i := range pending {
+			results[i].Err = err
+			c.metrics.produce.done(start, err)
+		}
+		return pending
+	}
+
+	promises := make([]workflow.Connection_putNewEvent_Results_Promise, len(pending))
+	spans := make([]*span, len(pending))
+
+	for j, i := range pending {
+		sctx, span := c.startSpan(ctx, "raven.produce")
+
+		message := messages[i]
+		message.MetaData = span.inject(message.MetaData)
+
+		promises[j] = putNewEvent(sctx, conn, message)
+		spans[j] = span
+	}
+
+	failed := pending[:0]
+	for j, i := range pending {
+		results[i].EventID, results[i].Err = c.eventID(promises[j])
+		c.metrics.produce.done(start, results[i].Err)
+		spans[j].end(results[i].Err)
+
+		if results[i].Err != nil {
+			failed = append(failed, i)
+		} else {
+			atomic.AddInt64(&c.counters.produced, 1)
+			c.metrics.produce.message(messages[i].Content)
+		}
+	}
+
+	return failed
+}
@@ -0,0 +1,137 @@
+package ravenworker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/dutchsec/raven-worker/workflow"
+	"github.com/gofrs/uuid"
+	context "golang.org/x/net/context"
+)
+
+func TestProduceBatch(t *testing.T) {
+	var m sync.Mutex
+
+	calls := map[string]int{}
+
+	srvr, err := testServer(&workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			evt, err := putNewEvent.Params.Event()
+			if err != nil {
+				return err
+			}
+
+			content, err := evt.Content()
+			if err != nil {
+				return err
+			}
+
+			m.Lock()
+			calls[string(content)]++
+			n := calls[string(content)]
+			m.Unlock()
+
+			// fail the first attempt of a single message.
+			if string(content) == "fail" && n == 1 {
+				return errors.New("ERROR")
+			}
+
+			eventID, _ := uuid.NewV4()
+			return putNewEvent.Results.SetEventID(eventID.Bytes())
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 1)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	messages := []Message{
+		{Content: StringContent("a")},
+		{Content: StringContent("fail")},
+		{Content: StringContent("b")},
+	}
+
+	results, err := w.ProduceBatch(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("Could not produce messages: %s", err.Error())
+	}
+
+	for i, result := range results {
+		if result.Err != nil {
+			t.Errorf("message %d failed: %s", i, result.Err)
+		} else if _, err := uuid.FromString(result.EventID.String()); err != nil {
+			t.Errorf("message %d has an invalid EventID: %s", i, result.EventID)
+		}
+	}
+
+	// only the failed message should have been retried.
+	if calls["a"] != 1 || calls["b"] != 1 || calls["fail"] != 2 {
+		t.Fatalf("unexpected number of calls: %v", calls)
+	}
+}
+
+func TestProduceBatchError(t *testing.T) {
+	srvr, err := testServer(&workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			evt, err := putNewEvent.Params.Event()
+			if err != nil {
+				return err
+			}
+
+			if content, _ := evt.Content(); string(content) == "fail" {
+				return errors.New("ERROR")
+			}
+
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithBackOff(StopBackOff),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	results, err := w.ProduceBatch(context.Background(), []Message{
+		{Content: StringContent("a")},
+		{Content: StringContent("fail")},
+	})
+	if err == nil {
+		t.Fatalf("Expected an error.")
+	}
+
+	if results[0].Err != nil {
+		t.Errorf("expected first message to succeed, got: %s", results[0].Err)
+	}
+
+	if results[1].Err != err {
+		t.Errorf("expected second message to fail with %v, got: %v", err, results[1].Err)
+	}
+}
@@ -1,6 +1,7 @@
 package ravenworker
 
 import (
+	"crypto/tls"
 	"errors"
 	"io"
 	"net/url"
@@ -16,14 +17,45 @@ type Config struct {
 
 	FlowID uuid.UUID
 
-	log Logger
+	log FieldLogger
+
+	authToken string // authenticates the worker on connect.
+
+	dialer DialFunc // opens connections to the Raven server. Nil dials tcp and unix urls.
+
+	tlsConfig *tls.Config // connect using TLS. Nil uses TLS for 'capnprotos' urls only.
 
 	newBackOff BackOffFunc
 
+	lazyConnect bool // connect on first use instead of in New.
+
 	consumeTimeout time.Duration // time frame to wait for a new message. Zero is no timeout.
 
 	maxIntake int // do not ingest more messages than this treshold.
 
+	concurrency int // number of messages handled at the same time by Run.
+
+	maxAttempts int        // number of times Run handles a message before it is dead lettered.
+	deadLetter  DeadLetter // receives messages that failed 'maxAttempts' times. Nil disables.
+
+	recoverPanics bool          // recover panics in the handler passed to Run.
+	recoverPolicy RecoverPolicy // what Run does after recovering a panic.
+
+	shutdownTimeout time.Duration // time RunUntilSignal waits for messages being handled after a signal.
+
+	keepAlive time.Duration // interval to extend the lease of messages handled by Run. Zero disables.
+
+	outboxSize    int // number of messages ProduceAsync queues before blocking.
+	outboxWorkers int // number of goroutines producing messages from the outbox.
+
+	spoolDir     string // directory to spool messages that could not be produced. Empty disables the spool.
+	spoolMaxSize int64  // maximum size of the spool in bytes. Zero is no limit.
+
+	exporter SpanExporter // receives the spans of the worker. Nil disables tracing.
+
+	healthAddr     string        // address to serve the health endpoints on. Empty disables.
+	readyThreshold time.Duration // time since the last successful GetJob or Ack before the worker is not ready.
+
 	closers []io.Closer
 }
 
@@ -2,6 +2,8 @@ package ravenworker
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v3"
@@ -12,31 +14,74 @@ import (
 var EmptyMessage = Message{}
 
 // Consume retrieves a message from workflow. When there are
-// no messages available, it will retry with a constant interval.
+// no messages available, it will keep polling with an increasing
+// interval.
 //
 // Messages can be retrieved by using Get(message)
 //
 // Messages can be acknowledged by using Ack(ref)
 //
-//     ref, err := w.Consume()
+//     ref, err := w.Consume(ctx)
 //     if err !=nil {
 //         panic(err)
 //     }
 //
-//     message, err := w.Get(ref)
+//     message, err := w.Get(ctx, ref)
 //     if err !=nil {
 //         panic(err)
 //     }
 //
-//     err := w.Ack(*message)
+//     err := w.Ack(ctx, ref)
 //     if err !=nil {
 //         panic(err)
 //     }
 //
 // Use this function for the 'transform' and 'load' worker types.
 // blocks until it receives a message or 'consumeTimeout' expires.
+//
+// When 'maxIntake' messages have been consumed, ErrMaxIntakeReached
+// is returned.
 func (c *DefaultWorker) Consume(ctx context.Context) (Reference, error) {
+	if !c.reserve() {
+		return Reference{}, ErrMaxIntakeReached
+	}
 
+	ref, err := c.consume(ctx)
+	if err != nil {
+		c.release()
+		return Reference{}, err
+	}
+
+	atomic.AddInt64(&c.intake, 1)
+	return ref, nil
+}
+
+// reserve reserves one of the 'maxIntake' messages before consuming,
+// so concurrent calls can't consume more. It returns false when all of
+// them have been consumed or are being consumed.
+func (c *DefaultWorker) reserve() bool {
+	if c.maxIntake <= 0 {
+		return true
+	}
+
+	for {
+		n := atomic.LoadInt64(&c.reserved)
+		if n >= int64(c.maxIntake) {
+			return false
+		} else if atomic.CompareAndSwapInt64(&c.reserved, n, n+1) {
+			return true
+		}
+	}
+}
+
+// release releases a reservation when consuming failed.
+func (c *DefaultWorker) release() {
+	if c.maxIntake > 0 {
+		atomic.AddInt64(&c.reserved, -1)
+	}
+}
+
+func (c *DefaultWorker) consume(ctx context.Context) (Reference, error) {
 	// there is no timeout, we wait forever to get a reference.
 	if c.consumeTimeout == 0 {
 		return c.waitForWork(ctx)
@@ -45,11 +90,21 @@ func (c *DefaultWorker) Consume(ctx context.Context) (Reference, error) {
 	ctxTimeout, cancel := context.WithTimeout(ctx, c.consumeTimeout)
 	defer cancel()
 
-	return c.waitForWork(ctxTimeout)
+	ref, err := c.waitForWork(ctxTimeout)
+	if err == context.DeadlineExceeded && ctx.Err() == nil {
+		return Reference{}, &Error{Kind: ErrTimeout, Err: err}
+	}
+
+	return ref, err
 }
 
-func IsNotFoundErr(err error) bool {
-	return err.Error() == "job.capnp:Connection.getJob: rpc exception: item not found"
+// newPollBackOff returns the backoff used to poll for new work while no
+// messages are available. It never stops, 'consumeTimeout' limits the
+// total time.
+var newPollBackOff = func() backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = 0
+	return b
 }
 
 // waitForWork will wait for work. If no work is available it will retry
@@ -57,18 +112,13 @@ func IsNotFoundErr(err error) bool {
 func (c *DefaultWorker) waitForWork(ctx context.Context) (Reference, error) {
 	var t *time.Timer
 
-	// new constant backoff with infinite retries, this could
-	// be changed in the future to automatically stop workers
-	// if no messages have been seen for a while. Formation
-	// should restart the worker if backlog is growing.
-	//var cb backoff.BackOff
-	cb := backoff.NewExponentialBackOff()
+	// the configured backoff decides how often we will retry
+	// when the connection has been lost.
+	poll := newPollBackOff()
+	cb := c.newBackOff()
 
 	for {
-		res, err := c.w.GetJob(ctx, func(params workflow.Connection_getJob_Params) error {
-			return nil
-		}).Struct()
-
+		res, err := c.getJob(ctx)
 		if err == nil {
 			ackID, _ := res.AckID()
 			ackUUID, _ := uuid.FromBytes(ackID)
@@ -77,19 +127,30 @@ func (c *DefaultWorker) waitForWork(ctx context.Context) (Reference, error) {
 			eventUUID, _ := uuid.FromBytes(eventID)
 
 			return Reference{
-				AckID:   ackUUID.String(),
-				EventID: eventUUID.String(),
+				AckID:      ackUUID.String(),
+				EventID:    eventUUID.String(),
+				Deliveries: int(res.Deliveries()),
 			}, nil
 		}
 
-		// we will loop only if no messages available
-		if !IsNotFoundErr(err) {
+		var next time.Duration
+
+		// we will loop only if no messages available or
+		// the connection has been lost.
+		if errors.Is(err, ErrNotFound) {
+			// the server answered, start over on the next connection loss.
+			cb.Reset()
+
+			next = poll.NextBackOff()
+		} else if !errors.Is(err, ErrConnectionLost) {
+			return Reference{}, err
+		} else if next = cb.NextBackOff(); next == backoff.Stop {
 			return Reference{}, err
+		} else {
+			c.metrics.consume.retry(next)
 		}
 
-		if next := cb.NextBackOff(); next == backoff.Stop {
-			return Reference{}, err
-		} else if t != nil {
+		if t != nil {
 			t.Reset(next)
 		} else {
 			t = time.NewTimer(next)
@@ -103,3 +164,32 @@ func (c *DefaultWorker) waitForWork(ctx context.Context) (Reference, error) {
 		}
 	}
 }
+
+func (c *DefaultWorker) getJob(ctx context.Context) (workflow.Connection_getJob_Results, error) {
+	conn, err := c.connection(ctx)
+	if err != nil {
+		return workflow.Connection_getJob_Results{}, err
+	}
+
+	start := time.Now()
+
+	ctx, span := c.startSpan(ctx, "raven.consume")
+
+	res, err := conn.GetJob(ctx, func(params workflow.Connection_getJob_Params) error {
+		return nil
+	}).Struct()
+
+	err = c.rpcError(err)
+
+	// no work available is a successful round trip as well.
+	if err == nil || errors.Is(err, ErrNotFound) {
+		c.counters.activity()
+		c.metrics.consume.done(start, nil)
+		span.end(nil)
+	} else {
+		c.metrics.consume.done(start, err)
+		span.end(err)
+	}
+
+	return res, err
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -19,7 +21,7 @@ func TestConsume(t *testing.T) {
 	eventID, _ := uuid.NewV4()
 
 	srvr, err := testServer(&workflowServer{
-		getJob: func(getJob workflow.Workflow_getJob) error {
+		getJob: func(getJob workflow.Connection_getJob) error {
 			getJob.Results.SetAckID(ackID.Bytes())
 			getJob.Results.SetEventID(eventID.Bytes())
 			return nil
@@ -64,12 +66,12 @@ func TestConsumeAck(t *testing.T) {
 	eventID, _ := uuid.NewV4()
 
 	srvr, err := testServer(&workflowServer{
-		getJob: func(getJob workflow.Workflow_getJob) error {
+		getJob: func(getJob workflow.Connection_getJob) error {
 			getJob.Results.SetAckID(ackID.Bytes())
 			getJob.Results.SetEventID(eventID.Bytes())
 			return nil
 		},
-		ackJob: func(ackJob workflow.Workflow_ackJob) error {
+		ackJob: func(ackJob workflow.Connection_ackJob) error {
 			if v, err := ackJob.Params.AckID(); err != nil {
 				return err
 			} else if id, err := uuid.FromBytes(v); err != nil {
@@ -104,7 +106,7 @@ func TestConsumeAck(t *testing.T) {
 		t.Fatalf("Could not consume message: %s", err.Error())
 	}
 
-	if err := w.Ack(ref); err != nil {
+	if err := w.Ack(context.Background(), ref); err != nil {
 		t.Errorf("Ack failed: %s", err.Error())
 	}
 }
@@ -118,12 +120,12 @@ func TestConsumeAckWithContent(t *testing.T) {
 	}
 
 	srvr, err := testServer(&workflowServer{
-		getJob: func(getJob workflow.Workflow_getJob) error {
+		getJob: func(getJob workflow.Connection_getJob) error {
 			getJob.Results.SetAckID(ackID.Bytes())
 			getJob.Results.SetEventID(eventID.Bytes())
 			return nil
 		},
-		ackJob: func(ackJob workflow.Workflow_ackJob) error {
+		ackJob: func(ackJob workflow.Connection_ackJob) error {
 			event, err := ackJob.Params.Event()
 			if err != nil {
 				return err
@@ -161,7 +163,7 @@ func TestConsumeAckWithContent(t *testing.T) {
 		t.Fatalf("Could not consume message: %s", err.Error())
 	}
 
-	if err := w.Ack(ref, WithMessage(msg)); err != nil {
+	if err := w.Ack(context.Background(), ref, WithMessage(msg)); err != nil {
 		t.Errorf("Ack failed: %s", err.Error())
 	}
 }
@@ -171,13 +173,13 @@ func TestConsumeGet(t *testing.T) {
 	eventID, _ := uuid.NewV4()
 
 	srvr, err := testServer(&workflowServer{
-		getJob: func(getJob workflow.Workflow_getJob) error {
+		getJob: func(getJob workflow.Connection_getJob) error {
 			getJob.Results.SetAckID(ackID.Bytes())
 			getJob.Results.SetEventID(eventID.Bytes())
 
 			return nil
 		},
-		getEvent: func(getEvent workflow.Workflow_getEvent) error {
+		getEvent: func(getEvent workflow.Connection_getEvent) error {
 			if v, err := getEvent.Params.EventID(); err != nil {
 				return err
 			} else if id, err := uuid.FromBytes(v); err != nil {
@@ -219,7 +221,7 @@ func TestConsumeGet(t *testing.T) {
 		t.Fatalf("Could not consume message: %s", err.Error())
 	}
 
-	message, err := w.Get(ref)
+	message, err := w.Get(context.Background(), ref)
 	if err != nil {
 		t.Errorf("Get failed: %s", err.Error())
 	}
@@ -232,13 +234,38 @@ func TestConsumeGet(t *testing.T) {
 	}
 }
 
+// fastPoll polls for new work every millisecond, until the returned
+// function is called.
+func fastPoll() func() {
+	fn := newPollBackOff
+
+	newPollBackOff = func() backoff.BackOff {
+		return backoff.NewConstantBackOff(time.Millisecond)
+	}
+
+	return func() {
+		newPollBackOff = fn
+	}
+}
+
 func TestBackOff(t *testing.T) {
+	defer fastPoll()()
+
+	ackID, _ := uuid.NewV4()
+	eventID, _ := uuid.NewV4()
+
 	counter := 0
 
 	srvr, err := testServer(&workflowServer{
-		getJob: func(getJob workflow.Workflow_getJob) error {
-			counter++
-			return errors.New("item not found")
+		getJob: func(getJob workflow.Connection_getJob) error {
+			// no messages for longer than the backoff retries.
+			if counter++; counter <= 10 {
+				return errors.New("item not found")
+			}
+
+			getJob.Results.SetAckID(ackID.Bytes())
+			getJob.Results.SetEventID(eventID.Bytes())
+			return nil
 		},
 	})
 	if err != nil {
@@ -252,7 +279,7 @@ func TestBackOff(t *testing.T) {
 		MustWithFlowID(flowID.String()),
 		MustWithWorkerID(workerID.String()),
 		MustWithLogger(DefaultLogger),
-		WithConsumeTimeout("200ms"),
+		MustWithConsumeTimeout("0s"),
 		WithBackOff(func() backoff.BackOff {
 			cb := &backoff.ZeroBackOff{}
 			return backoff.WithMaxRetries(cb, 5)
@@ -262,12 +289,13 @@ func TestBackOff(t *testing.T) {
 		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
 	}
 
-	// we are expecting an error
-	if _, err := w.Consume(context.Background()); err == nil {
-		t.Fatalf("Expected an error.")
+	// the backoff only limits retries when the connection is lost,
+	// we keep polling until there is a message.
+	if _, err := w.Consume(context.Background()); err != nil {
+		t.Fatalf("Could not consume message: %s", err.Error())
 	}
 
-	if counter != 6 {
+	if counter != 11 {
 		t.Fatalf("Backoff failed %d", counter)
 	}
 }
@@ -275,7 +303,7 @@ func TestBackOff(t *testing.T) {
 func TestConsumeTimeout(t *testing.T) {
 
 	srvr, err := testServer(&workflowServer{
-		getJob: func(getJob workflow.Workflow_getJob) error {
+		getJob: func(getJob workflow.Connection_getJob) error {
 			time.Sleep(200 * time.Millisecond)
 			return errors.New("Don't want this error")
 		},
@@ -290,7 +318,7 @@ func TestConsumeTimeout(t *testing.T) {
 		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
 		MustWithFlowID(flowID.String()),
 		MustWithWorkerID(workerID.String()),
-		WithConsumeTimeout("10ms"),
+		MustWithConsumeTimeout("10ms"),
 		MustWithLogger(DefaultLogger),
 		WithBackOff(func() backoff.BackOff {
 			return backoff.NewConstantBackOff(3 * time.Millisecond)
@@ -303,15 +331,15 @@ func TestConsumeTimeout(t *testing.T) {
 	// we are expecting an error
 	if _, err := w.Consume(context.Background()); err == nil {
 		t.Fatalf("Expected an error.")
-	} else if err != context.DeadlineExceeded {
-		t.Fatalf("expected error %v, got: %v", context.DeadlineExceeded, err)
+	} else if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected error %v, got: %v", ErrTimeout, err)
 	}
 }
 
 func TestConsumeWithError(t *testing.T) {
 
 	srvr, err := testServer(&workflowServer{
-		getJob: func(getJob workflow.Workflow_getJob) error {
+		getJob: func(getJob workflow.Connection_getJob) error {
 			return errors.New("ERROR")
 		},
 	})
@@ -325,7 +353,7 @@ func TestConsumeWithError(t *testing.T) {
 		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
 		MustWithFlowID(flowID.String()),
 		MustWithWorkerID(workerID.String()),
-		WithConsumeTimeout("10ms"),
+		MustWithConsumeTimeout("10ms"),
 		MustWithLogger(DefaultLogger),
 		WithBackOff(func() backoff.BackOff {
 			return backoff.NewConstantBackOff(3 * time.Millisecond)
@@ -335,7 +363,7 @@ func TestConsumeWithError(t *testing.T) {
 		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
 	}
 
-	e := "job.capnp:Workflow.getJob: rpc exception: ERROR"
+	e := "job.capnp:Connection.getJob: rpc exception: ERROR"
 
 	// we are expecting an error
 	if _, err := w.Consume(context.Background()); err == nil {
@@ -348,7 +376,7 @@ func TestConsumeWithError(t *testing.T) {
 func TestConsumeWithCancel(t *testing.T) {
 
 	srvr, err := testServer(&workflowServer{
-		getJob: func(getJob workflow.Workflow_getJob) error {
+		getJob: func(getJob workflow.Connection_getJob) error {
 			return errors.New("item not found")
 		},
 	})
@@ -362,7 +390,7 @@ func TestConsumeWithCancel(t *testing.T) {
 		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
 		MustWithFlowID(flowID.String()),
 		MustWithWorkerID(workerID.String()),
-		WithConsumeTimeout("100ms"),
+		MustWithConsumeTimeout("100ms"),
 		MustWithLogger(DefaultLogger),
 		WithBackOff(func() backoff.BackOff {
 			return backoff.NewConstantBackOff(3 * time.Millisecond)
@@ -382,3 +410,120 @@ func TestConsumeWithCancel(t *testing.T) {
 		t.Fatalf("expected error %v, got: %v", context.Canceled, err)
 	}
 }
+
+func TestConsumeMaxIntake(t *testing.T) {
+	ackID, _ := uuid.NewV4()
+	eventID, _ := uuid.NewV4()
+
+	srvr, err := testServer(&workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			getJob.Results.SetAckID(ackID.Bytes())
+			getJob.Results.SetEventID(eventID.Bytes())
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithMaxIntake("2"),
+		WithBackOff(StopBackOff),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := w.Consume(context.Background()); err != nil {
+			t.Fatalf("Could not consume message: %s", err.Error())
+		}
+	}
+
+	if _, err := w.Consume(context.Background()); err != ErrMaxIntakeReached {
+		t.Fatalf("expected error %v, got: %v", ErrMaxIntakeReached, err)
+	}
+}
+
+func TestConsumeMaxIntakeConcurrent(t *testing.T) {
+	var calls int32
+
+	srvr, err := testServer(&workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			// the first call doesn't find a message.
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return errors.New("item not found")
+			}
+
+			time.Sleep(50 * time.Millisecond)
+
+			ackID, _ := uuid.NewV4()
+			eventID, _ := uuid.NewV4()
+
+			getJob.Results.SetAckID(ackID.Bytes())
+			getJob.Results.SetEventID(eventID.Bytes())
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithMaxIntake("2"),
+		WithBackOff(StopBackOff),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// a failed consume doesn't count.
+	if _, err := w.Consume(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected error %v, got: %v", context.DeadlineExceeded, err)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		consumed int32
+		reached  int32
+	)
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := w.Consume(context.Background()); err == nil {
+				atomic.AddInt32(&consumed, 1)
+			} else if err == ErrMaxIntakeReached {
+				atomic.AddInt32(&reached, 1)
+			} else {
+				t.Errorf("Could not consume message: %s", err.Error())
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&consumed); n != 2 {
+		t.Fatalf("Expected 2 consumed messages, got %d", n)
+	} else if n := atomic.LoadInt32(&reached); n != 3 {
+		t.Fatalf("Expected max intake to be reached 3 times, got %d", n)
+	}
+}
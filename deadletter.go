@@ -0,0 +1,207 @@
+package ravenworker
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Metadata keys set on dead lettered messages.
+const (
+	MetaDeadLetter         = "dead-letter"
+	MetaDeadLetterError    = "dead-letter-error"
+	MetaDeadLetterAttempts = "dead-letter-attempts"
+	MetaDeadLetterEventID  = "dead-letter-event-id"
+)
+
+// DeadLetter receives messages that failed too often, see
+// WithDeadLetter. The message has the dead letter metadata set.
+type DeadLetter interface {
+	DeadLetter(ctx context.Context, ref Reference, message Message, err error) error
+}
+
+// DeadLetterFunc is a function implementing DeadLetter.
+type DeadLetterFunc func(ctx context.Context, ref Reference, message Message, err error) error
+
+func (fn DeadLetterFunc) DeadLetter(ctx context.Context, ref Reference, message Message, err error) error {
+	return fn(ctx, ref, message, err)
+}
+
+// DeadLetterWorker produces dead lettered messages with w, which should
+// be connected to the dead letter flow.
+//
+//     dlw, err := New(CustomEnvironment(ravenURL, deadLetterFlowID, workerID))
+//     ...
+//     w, err := New(DefaultEnvironment(), WithDeadLetter(5, DeadLetterWorker(dlw)))
+func DeadLetterWorker(w Worker) DeadLetter {
+	return DeadLetterFunc(func(ctx context.Context, ref Reference, message Message, err error) error {
+		_, perr := w.Produce(ctx, message)
+		return perr
+	})
+}
+
+// DeadLetterFile appends dead lettered messages as json lines to the
+// file at path.
+func DeadLetterFile(path string) DeadLetter {
+	var m sync.Mutex
+
+	return DeadLetterFunc(func(ctx context.Context, ref Reference, message Message, err error) error {
+		data, jerr := json.Marshal(struct {
+			Time      time.Time `json:"time"`
+			Reference Reference `json:"reference"`
+			Error     string    `json:"error"`
+			Message   Message   `json:"message"`
+		}{
+			Time:      time.Now(),
+			Reference: ref,
+			Error:     err.Error(),
+			Message:   message,
+		})
+		if jerr != nil {
+			return jerr
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		f, ferr := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if ferr != nil {
+			return ferr
+		}
+
+		if _, ferr := f.Write(append(data, '\n')); ferr != nil {
+			f.Close()
+			return ferr
+		}
+
+		if ferr := f.Sync(); ferr != nil {
+			f.Close()
+			return ferr
+		}
+
+		return f.Close()
+	})
+}
+
+// failed requeues the message when the handler failed, or passes it to
+// the dead letter once it failed 'maxAttempts' times.
+func (c *DefaultWorker) failed(ctx context.Context, ref Reference, message Message, err error) error {
+	var n int
+
+	// the server knows about deliveries to other workers, count
+	// locally only when it doesn't report them.
+	if ref.Deliveries > 0 {
+		c.attempts.forget(ref.EventID)
+		n = ref.Deliveries
+	} else {
+		n = c.attempts.fail(ref.EventID)
+	}
+
+	if n < c.maxAttempts {
+		c.log.Infow("Requeueing message", "event_id", ref.EventID, "ack_id", ref.AckID, "attempt", n, "max_attempts", c.maxAttempts)
+		return c.Nack(ctx, ref, WithRequeue(), WithReason(err.Error()))
+	}
+
+	c.attempts.forget(ref.EventID)
+
+	c.log.Errorw("Message failed, dead lettering", "event_id", ref.EventID, "ack_id", ref.AckID, "attempt", n, "error", err)
+
+	message = deadLettered(ref, message, n, err)
+
+	if dlerr := c.deadLetter.DeadLetter(ctx, ref, message, err); dlerr != nil {
+		c.log.Errorw("Could not dead letter message", "event_id", ref.EventID, "ack_id", ref.AckID, "error", dlerr)
+		return dlerr
+	}
+
+	return c.Ack(ctx, ref, WithMessage(message), WithFilter())
+}
+
+// deadLettered returns a copy of message with the dead letter metadata.
+func deadLettered(ref Reference, message Message, attempts int, err error) Message {
+	metadata := make([]Metadata, 0, len(message.MetaData)+4)
+	metadata = append(metadata, message.MetaData...)
+	metadata = append(metadata,
+		Metadata{Key: MetaDeadLetter, Value: "true"},
+		Metadata{Key: MetaDeadLetterError, Value: err.Error()},
+		Metadata{Key: MetaDeadLetterAttempts, Value: strconv.Itoa(attempts)},
+		Metadata{Key: MetaDeadLetterEventID, Value: ref.EventID},
+	)
+
+	return Message{
+		Content:  message.Content,
+		MetaData: metadata,
+	}
+}
+
+// attemptsSize is the number of messages attempts keeps count of.
+const attemptsSize = 10000
+
+// attempts counts the failed deliveries per EventID. A requeued message
+// may be delivered to another worker and never come back, so only the
+// 'size' most recently failed messages are kept.
+type attempts struct {
+	size int
+
+	m      sync.Mutex
+	order  *list.List // of *attempt, most recently failed first.
+	failed map[string]*list.Element
+}
+
+type attempt struct {
+	eventID string
+	n       int
+}
+
+func newAttempts(size int) *attempts {
+	return &attempts{
+		size:   size,
+		order:  list.New(),
+		failed: map[string]*list.Element{},
+	}
+}
+
+// fail records a failed delivery and returns the number of failures.
+func (a *attempts) fail(eventID string) int {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if e, ok := a.failed[eventID]; ok {
+		a.order.MoveToFront(e)
+
+		v := e.Value.(*attempt)
+		v.n++
+		return v.n
+	}
+
+	a.failed[eventID] = a.order.PushFront(&attempt{eventID: eventID, n: 1})
+
+	if a.order.Len() > a.size {
+		e := a.order.Back()
+		a.order.Remove(e)
+		delete(a.failed, e.Value.(*attempt).eventID)
+	}
+
+	return 1
+}
+
+func (a *attempts) forget(eventID string) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if e, ok := a.failed[eventID]; ok {
+		a.order.Remove(e)
+		delete(a.failed, eventID)
+	}
+}
+
+// len returns the number of messages being counted.
+func (a *attempts) len() int {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	return a.order.Len()
+}
@@ -0,0 +1,257 @@
+package ravenworker
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/dutchsec/raven-worker/workflow"
+	"github.com/gofrs/uuid"
+	context "golang.org/x/net/context"
+)
+
+// deadLetterServer hands out the same job jobs times, reporting
+// deliveries.
+func deadLetterServer(jobs int32, deliveries uint32, nacks, acks *int32, filtered *int32) *workflowServer {
+	ackID, _ := uuid.NewV4()
+	eventID, _ := uuid.NewV4()
+
+	var n int32
+
+	return &workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			if atomic.AddInt32(&n, 1) > jobs {
+				return errors.New("item not found")
+			}
+
+			getJob.Results.SetAckID(ackID.Bytes())
+			getJob.Results.SetEventID(eventID.Bytes())
+			getJob.Results.SetDeliveries(deliveries)
+			return nil
+		},
+		getEvent: func(getEvent workflow.Connection_getEvent) error {
+			evt, err := getEvent.Results.NewEvent()
+			if err != nil {
+				return err
+			}
+
+			evt.SetContent(StringContent("poison"))
+			return getEvent.Results.SetEvent(evt)
+		},
+		nackJob: func(nackJob workflow.Connection_nackJob) error {
+			if !nackJob.Params.Requeue() {
+				return fmt.Errorf("Expected requeue")
+			}
+
+			atomic.AddInt32(nacks, 1)
+
+			nackJob.Results.SetNacked(true)
+			return nil
+		},
+		ackJob: func(ackJob workflow.Connection_ackJob) error {
+			event, err := ackJob.Params.Event()
+			if err != nil {
+				return err
+			}
+
+			meta, err := event.Meta()
+			if err != nil {
+				return err
+			}
+
+			found := false
+			for _, md := range transformMeta(meta) {
+				if md.Key == MetaDeadLetter && md.Value == "true" {
+					found = true
+				}
+			}
+
+			if !found {
+				return fmt.Errorf("Expected dead letter metadata")
+			}
+
+			if event.Filter() {
+				atomic.AddInt32(filtered, 1)
+			}
+
+			atomic.AddInt32(acks, 1)
+
+			ackJob.Results.SetAcked(true)
+			return nil
+		},
+	}
+}
+
+func TestRunDeadLetter(t *testing.T) {
+	var nacks, acks, filtered int32
+
+	srvr, err := testServer(deadLetterServer(3, 0, &nacks, &acks, &filtered))
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	dir, err := ioutil.TempDir("", "deadletter")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "deadletter.json")
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		MustWithConsumeTimeout("100ms"),
+		WithDeadLetter(3, DeadLetterFile(path)),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	err = w.Run(context.Background(), func(ctx context.Context, message Message) (Result, error) {
+		return Result{}, errors.New("cannot handle poison")
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %s", err.Error())
+	}
+
+	if n := atomic.LoadInt32(&nacks); n != 2 {
+		t.Fatalf("Expected 2 nacks, got %d", n)
+	}
+
+	if n := atomic.LoadInt32(&acks); n != 1 {
+		t.Fatalf("Expected 1 ack, got %d", n)
+	}
+
+	if n := atomic.LoadInt32(&filtered); n != 1 {
+		t.Fatalf("Expected dead letter to be filtered, got %d", n)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Could not open dead letter file: %s", err)
+	}
+
+	defer f.Close()
+
+	lines := 0
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		v := struct {
+			Error   string  `json:"error"`
+			Message Message `json:"message"`
+		}{}
+
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			t.Fatalf("Could not decode dead letter: %s", err)
+		}
+
+		if v.Error != "cannot handle poison" {
+			t.Fatalf("Incorrect error: %s", v.Error)
+		}
+
+		if string(v.Message.Content) != "poison" {
+			t.Fatalf("Incorrect content: %s", string(v.Message.Content))
+		}
+
+		lines++
+	}
+
+	if lines != 1 {
+		t.Fatalf("Expected 1 dead letter, got %d", lines)
+	}
+}
+
+func TestRunDeadLetterDeliveries(t *testing.T) {
+	var nacks, acks, filtered, letters int32
+
+	// the server handed out the message to other workers before.
+	srvr, err := testServer(deadLetterServer(1, 5, &nacks, &acks, &filtered))
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		MustWithConsumeTimeout("100ms"),
+		WithDeadLetter(3, DeadLetterFunc(func(ctx context.Context, ref Reference, message Message, err error) error {
+			atomic.AddInt32(&letters, 1)
+			return nil
+		})),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	err = w.Run(context.Background(), func(ctx context.Context, message Message) (Result, error) {
+		return Result{}, errors.New("cannot handle poison")
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %s", err.Error())
+	}
+
+	if n := atomic.LoadInt32(&nacks); n != 0 {
+		t.Fatalf("Expected no nacks, got %d", n)
+	}
+
+	if n := atomic.LoadInt32(&letters); n != 1 {
+		t.Fatalf("Expected 1 dead letter, got %d", n)
+	}
+}
+
+func TestAttempts(t *testing.T) {
+	a := newAttempts(2)
+
+	a.fail("a")
+	a.fail("b")
+
+	if n := a.fail("a"); n != 2 {
+		t.Fatalf("Expected 2 failures, got %d", n)
+	}
+
+	// b failed least recently and is forgotten.
+	a.fail("c")
+
+	if n := a.len(); n != 2 {
+		t.Fatalf("Expected 2 messages, got %d", n)
+	}
+
+	if n := a.fail("b"); n != 1 {
+		t.Fatalf("Expected b to be forgotten, got %d failures", n)
+	}
+
+	a.forget("b")
+	a.forget("c")
+
+	if n := a.len(); n != 0 {
+		t.Fatalf("Expected no messages, got %d", n)
+	}
+}
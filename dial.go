@@ -0,0 +1,50 @@
+package ravenworker
+
+import (
+	"context"
+	"net"
+	"net/url"
+)
+
+// DialFunc opens a connection to the Raven server at u, see WithDialer.
+// On reconnect ctx is the context of the call that lost the connection,
+// the dial should be aborted when it is done.
+type DialFunc func(ctx context.Context, u *url.URL) (net.Conn, error)
+
+// dialURL opens a connection to the server at u. 'unix' urls connect
+// to the socket at the path of the url, other urls to the tcp host.
+func (w *DefaultWorker) dialURL(ctx context.Context, u url.URL) (net.Conn, error) {
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	if w.dialer != nil {
+		conn, err = w.dialer(ctx, &u)
+	} else if u.Scheme == "unix" {
+		conn, err = dialContext(ctx, "unix", socketPath(u))
+	} else {
+		conn, err = dialContext(ctx, "tcp", u.Host)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !w.useTLS(u) {
+		return conn, nil
+	}
+
+	return w.tlsClient(ctx, conn, u)
+}
+
+func dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, network, address)
+}
+
+// socketPath returns the path of a unix url, both 'unix:///path' and
+// 'unix://relative/path' are accepted.
+func socketPath(u url.URL) string {
+	return u.Host + u.Path
+}
@@ -0,0 +1,160 @@
+package ravenworker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dutchsec/raven-worker/workflow"
+	context "golang.org/x/net/context"
+)
+
+func TestUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unix")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "raven.sock")
+
+	nl, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Could not listen on unix socket: %s", err)
+	}
+
+	var produced int32
+
+	srvr := testServe(nl, &workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			atomic.AddInt32(&produced, 1)
+			return nil
+		},
+	})
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("unix://%s", path)),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	if _, err := w.Produce(context.Background(), TestProduceMessage); err != nil {
+		t.Fatalf("Could not produce message: %s", err.Error())
+	}
+
+	if n := atomic.LoadInt32(&produced); n != 1 {
+		t.Fatalf("Expected 1 produced message, got %d", n)
+	}
+}
+
+func TestWithDialer(t *testing.T) {
+	var produced, dials int32
+
+	ws := &workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			atomic.AddInt32(&produced, 1)
+			return nil
+		},
+	}
+
+	w, err := New(
+		MustWithRavenURL("capnproto://raven.internal:8023"),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithDialer(func(ctx context.Context, u *url.URL) (net.Conn, error) {
+			if u.Host != "raven.internal:8023" {
+				return nil, fmt.Errorf("Incorrect host: %s", u.Host)
+			}
+
+			atomic.AddInt32(&dials, 1)
+
+			client, server := net.Pipe()
+			go testServeConn(server, ws)
+
+			return client, nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	if _, err := w.Produce(context.Background(), TestProduceMessage); err != nil {
+		t.Fatalf("Could not produce message: %s", err.Error())
+	}
+
+	if n := atomic.LoadInt32(&dials); n != 1 {
+		t.Fatalf("Expected 1 dial, got %d", n)
+	}
+
+	if n := atomic.LoadInt32(&produced); n != 1 {
+		t.Fatalf("Expected 1 produced message, got %d", n)
+	}
+}
+
+type dialKey struct{}
+
+func TestWithDialerContext(t *testing.T) {
+	ws := &workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			return nil
+		},
+	}
+
+	var (
+		value    interface{}
+		deadline bool
+	)
+
+	w, err := New(
+		MustWithRavenURL("capnproto://raven.internal:8023"),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithLazyConnect(),
+		WithDialer(func(ctx context.Context, u *url.URL) (net.Conn, error) {
+			value = ctx.Value(dialKey{})
+			_, deadline = ctx.Deadline()
+
+			client, server := net.Pipe()
+			go testServeConn(server, ws)
+
+			return client, nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), dialKey{}, "produce"), time.Second)
+	defer cancel()
+
+	if _, err := w.Produce(ctx, TestProduceMessage); err != nil {
+		t.Fatalf("Could not produce message: %s", err.Error())
+	}
+
+	if value != "produce" {
+		t.Fatalf("Expected the dialer to get the context of Produce, got %v", value)
+	} else if !deadline {
+		t.Fatalf("Expected the dialer context to have a deadline")
+	}
+}
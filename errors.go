@@ -0,0 +1,143 @@
+package ravenworker
+
+import (
+	"errors"
+	"strings"
+
+	capnp "zombiezen.com/go/capnproto2"
+	"zombiezen.com/go/capnproto2/rpc"
+	rpccapnp "zombiezen.com/go/capnproto2/std/capnp/rpc"
+)
+
+var (
+	// ErrNotFound is reported by the server when there is no message
+	// available, Consume keeps polling until 'consumeTimeout' expires.
+	ErrNotFound = errors.New("item not found")
+
+	// ErrConnectionLost is returned when the connection to the
+	// Raven server has been lost.
+	ErrConnectionLost = errors.New("connection lost")
+
+	// ErrTimeout is returned when no message has been received
+	// within 'consumeTimeout'.
+	ErrTimeout = errors.New("timed out")
+
+	// ErrMaxIntakeReached is returned by Consume when the number of
+	// messages set by WithMaxIntake has been consumed.
+	ErrMaxIntakeReached = errors.New("max intake reached")
+
+	// ErrInvalidReference is returned when the reference does not
+	// contain a valid AckID or EventID.
+	ErrInvalidReference = errors.New("invalid reference")
+
+	// ErrAckRejected is returned when the server did not accept the
+	// acknowledgement.
+	ErrAckRejected = errors.New("ack rejected by server")
+
+	// ErrNackRejected is returned when the server did not accept the
+	// negative acknowledgement.
+	ErrNackRejected = errors.New("nack rejected by server")
+
+	// ErrClosed is returned when the worker has been closed.
+	ErrClosed = errors.New("worker closed")
+
+	// ErrUnauthorized is returned when the server rejected the worker,
+	// for example because of an invalid auth token.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrLeaseLost is returned when the server no longer holds the
+	// message for this worker, it may be handed out to another worker.
+	ErrLeaseLost = errors.New("lease lost")
+)
+
+// Error wraps the underlying error, usually returned by the rpc
+// connection, with one of the Err* kinds. Use errors.Is to check
+// the kind:
+//
+//     if errors.Is(err, ErrConnectionLost) {
+//         // retry later
+//     }
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Kind.Error()
+	}
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+// Is reports whether target is the kind of this error.
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// IsNotFoundErr returns true when no message is available.
+func IsNotFoundErr(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// rpcError wraps an error returned by an rpc call with its kind.
+// Errors that could not be classified are returned as is.
+func (c *DefaultWorker) rpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var kind error
+
+	switch {
+	case c.isClosed():
+		kind = ErrClosed
+	case isException(err, "item not found"):
+		kind = ErrNotFound
+	case isException(err, "unauthorized"):
+		kind = ErrUnauthorized
+	case err == rpc.ErrConnClosed || c.connectionLost():
+		kind = ErrConnectionLost
+	default:
+		return err
+	}
+
+	return &Error{Kind: kind, Err: err}
+}
+
+// permanent returns true when retrying err will not help.
+func permanent(err error) bool {
+	return errors.Is(err, ErrClosed) || errors.Is(err, ErrUnauthorized)
+}
+
+// rejected returns true when the server failed the call itself, so
+// sending the same request again will fail again. Overloaded and
+// disconnected exceptions are temporary.
+func rejected(err error) bool {
+	if errors.Is(err, ErrConnectionLost) || permanent(err) {
+		return false
+	}
+
+	// the exception is wrapped with the method that failed.
+	var merr *capnp.MethodError
+	if errors.As(err, &merr) {
+		err = merr.Err
+	}
+
+	var exc rpc.Exception
+	if !errors.As(err, &exc) {
+		return false
+	}
+
+	return exc.Type() == rpccapnp.Exception_Type_failed
+}
+
+// isException returns true if err is an rpc exception with reason.
+// The server only reports the reason of an exception, so this is the
+// single place where we need to look at the error message.
+func isException(err error, reason string) bool {
+	return strings.HasSuffix(err.Error(), "rpc exception: "+reason)
+}
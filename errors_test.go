@@ -0,0 +1,135 @@
+package ravenworker
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dutchsec/raven-worker/workflow"
+	"github.com/gofrs/uuid"
+	context "golang.org/x/net/context"
+)
+
+func TestIsNotFoundErrNil(t *testing.T) {
+	if IsNotFoundErr(nil) {
+		t.Fatal("expected <nil> not to be a not found error")
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	cause := errors.New("cause")
+
+	err := fmt.Errorf("wrapped: %w", &Error{Kind: ErrConnectionLost, Err: cause})
+
+	if !errors.Is(err, ErrConnectionLost) {
+		t.Errorf("expected error to be %v", ErrConnectionLost)
+	}
+
+	if !errors.Is(err, cause) {
+		t.Errorf("expected error to wrap %v", cause)
+	}
+
+	if errors.Is(err, ErrNotFound) {
+		t.Errorf("expected error not to be %v", ErrNotFound)
+	}
+
+	var e *Error
+	if !errors.As(err, &e) || e.Kind != ErrConnectionLost {
+		t.Errorf("expected errors.As to return the Error")
+	}
+}
+
+func TestGetJobNotFound(t *testing.T) {
+	srvr, err := testServer(&workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			return errors.New("item not found")
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithBackOff(StopBackOff),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	// Consume keeps polling, the error of a single poll has the kind.
+	if _, err := w.(*DefaultWorker).getJob(context.Background()); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected error %v, got: %v", ErrNotFound, err)
+	}
+}
+
+func TestAckRejected(t *testing.T) {
+	var counter int
+
+	srvr, err := testServer(&workflowServer{
+		ackJob: func(ackJob workflow.Connection_ackJob) error {
+			counter++
+
+			ackJob.Results.SetAcked(false)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	ackID, _ := uuid.NewV4()
+
+	if err := w.Ack(context.Background(), Reference{AckID: ackID.String()}); !errors.Is(err, ErrAckRejected) {
+		t.Fatalf("expected error %v, got: %v", ErrAckRejected, err)
+	}
+
+	// a rejected ack should not be retried.
+	if counter != 1 {
+		t.Fatalf("expected 1 ack, got %d", counter)
+	}
+}
+
+func TestInvalidReference(t *testing.T) {
+	srvr, err := testServer(&workflowServer{})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	if _, err := w.Get(context.Background(), Reference{EventID: "invalid"}); !errors.Is(err, ErrInvalidReference) {
+		t.Errorf("expected error %v, got: %v", ErrInvalidReference, err)
+	}
+
+	if err := w.Ack(context.Background(), Reference{AckID: "invalid"}); !errors.Is(err, ErrInvalidReference) {
+		t.Errorf("expected error %v, got: %v", ErrInvalidReference, err)
+	}
+}
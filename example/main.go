@@ -1,10 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"time"
 
-	ravenworker "github.com/dutchsec/raven-worker"
 	worker "github.com/dutchsec/raven-worker"
 )
 
@@ -30,9 +30,12 @@ func exampleProduce() {
 
 		message.Content = worker.StringContent(fmt.Sprintf("message %v", time.Now().String()))
 
-		if err := c.Produce(message); err != nil {
+		eventID, err := c.Produce(context.Background(), message)
+		if err != nil {
 			log.Fatalf("Could not produce events: %s", err)
 		}
+
+		log.Infof("Produced event: %s", eventID)
 	}
 }
 
@@ -46,21 +49,13 @@ func exampleTransform() {
 		log.Fatalf("Could not initialize raven worker: %s", err)
 	}
 
-	for {
-		ref, err := c.Consume()
-		if err != nil {
-			log.Fatalf("Could not consume message: %s", err)
-		}
-
-		message, err := c.Get(ref)
-		if err != nil {
-			log.Fatalf("Could not get message: %s", err)
-		}
-
+	// stop on SIGTERM, after acknowledging the message being handled.
+	err = c.RunUntilSignal(context.Background(), func(ctx context.Context, message worker.Message) (worker.Result, error) {
 		message.Content = worker.StringContent("test")
 
-		if err := c.Ack(ref, ravenworker.WithMessage(message)); err != nil {
-			log.Fatalf("Could not ack message: %s", err)
-		}
+		return worker.Result{Message: &message}, nil
+	})
+	if err != nil {
+		log.Fatalf("Could not process messages: %s", err)
 	}
 }
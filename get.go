@@ -11,35 +11,63 @@ import (
 
 // Get will retrieve the event for reference.
 //
-//     msg,  err := Get(ref)
+//     msg,  err := Get(ctx, ref)
 //     if err != nil {
 //         // handle error
 //     }
-func (c *DefaultWorker) Get(ref Reference) (Message, error) {
+//
+// The context will cancel the request and any retries.
+func (c *DefaultWorker) Get(ctx context.Context, ref Reference) (Message, error) {
+	eventID, err := uuid.FromString(ref.EventID)
+	if err != nil {
+		return Message{}, &Error{Kind: ErrInvalidReference, Err: err}
+	}
+
 	var t *time.Timer
 
 	cb := c.newBackOff()
 
-	for {
-		m, err := c.get(ref)
+	log := c.log.With(Field{"event_id", ref.EventID}, Field{"ack_id", ref.AckID})
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+
+		sctx, span := c.startSpan(ctx, "raven.get")
+		span.set("event_id", ref.EventID)
+
+		m, err := c.get(sctx, eventID)
+		c.metrics.get.done(start, err)
+		span.end(err)
+
 		if err == nil {
+			c.metrics.get.message(m.Content)
 			return m, nil
+		} else if permanent(err) {
+			return Message{}, err
 		}
 
 		next := cb.NextBackOff()
 		if next == backoff.Stop {
-			c.log.Errorf("Could not get message: %s", err)
+			log.Errorw("Could not get message", "error", err, "attempt", attempt)
 			return Message{}, err
-		} else if t != nil {
+		}
+
+		c.metrics.get.retry(next)
+
+		if t != nil {
 			t.Reset(next)
 		} else {
 			t = time.NewTimer(next)
 			defer t.Stop()
 		}
 
-		c.log.Debugf("Got error while get message for: %s. Will retry in %v.", err.Error(), next)
+		log.Debugw("Got error while get message, will retry", "error", err, "attempt", attempt, "retry_in", next)
 
-		<-t.C
+		select {
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		case <-t.C:
+		}
 	}
 }
 
@@ -57,15 +85,18 @@ func transformMeta(md workflow.Event_Metadata_List) []Metadata {
 	return metadata
 }
 
-func (c *DefaultWorker) get(ref Reference) (Message, error) {
-	eventID, _ := uuid.FromString(ref.EventID)
+func (c *DefaultWorker) get(ctx context.Context, eventID uuid.UUID) (Message, error) {
+	conn, err := c.connection(ctx)
+	if err != nil {
+		return Message{}, err
+	}
 
-	res, err := c.w.GetEvent(context.Background(), func(params workflow.Connection_getEvent_Params) error {
+	res, err := conn.GetEvent(ctx, func(params workflow.Connection_getEvent_Params) error {
 		return params.SetEventID(eventID.Bytes())
 	}).Struct()
 
 	if err != nil {
-		return Message{}, err
+		return Message{}, c.rpcError(err)
 	}
 
 	event, err := res.Event()
@@ -0,0 +1,123 @@
+package ravenworker
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// counters of the worker, allocated separately for 64-bit alignment.
+type counters struct {
+	acked    int64
+	nacked   int64
+	produced int64
+
+	lastActivity int64 // unix nano of the last successful GetJob or Ack.
+}
+
+// activity records a successful round trip to the server.
+func (c *counters) activity() {
+	atomic.StoreInt64(&c.lastActivity, time.Now().UnixNano())
+}
+
+// Status of the worker, served as json on /status.
+type Status struct {
+	FlowID       string     `json:"flow_id"`
+	WorkerID     string     `json:"worker_id"`
+	URL          string     `json:"url"`
+	Connected    bool       `json:"connected"`
+	Ready        bool       `json:"ready"`
+	LastActivity time.Time  `json:"last_activity"`
+	Consumed     int64      `json:"consumed"`
+	Acked        int64      `json:"acked"`
+	Nacked       int64      `json:"nacked"`
+	Produced     int64      `json:"produced"`
+	Spool        SpoolStats `json:"spool"`
+}
+
+// Status returns the current status of the worker. It doesn't wait for
+// a reconnect in progress, which reports the worker as disconnected.
+func (c *DefaultWorker) Status() Status {
+	c.m.Lock()
+	connected := !c.closed && !c.disconnected()
+	url := c.url
+	c.m.Unlock()
+
+	lastActivity := time.Unix(0, atomic.LoadInt64(&c.counters.lastActivity))
+
+	return Status{
+		FlowID:       c.FlowID.String(),
+		WorkerID:     c.WorkerID.String(),
+		URL:          url,
+		Connected:    connected,
+		Ready:        connected && time.Since(lastActivity) <= c.readyThreshold,
+		LastActivity: lastActivity,
+		Consumed:     atomic.LoadInt64(&c.intake),
+		Acked:        atomic.LoadInt64(&c.counters.acked),
+		Nacked:       atomic.LoadInt64(&c.counters.nacked),
+		Produced:     atomic.LoadInt64(&c.counters.produced),
+		Spool:        c.SpoolStats(),
+	}
+}
+
+// HealthHandler returns the handler serving the health endpoints, use
+// it to serve them on your own http server instead of WithHealthAddr.
+//
+//     /healthz  the process is alive
+//     /readyz   connected, with a successful GetJob or Ack within 'readyThreshold'
+//     /status   the Status as json
+//     /metrics  the metrics in Prometheus text format
+func (c *DefaultWorker) HealthHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !c.Status().Ready {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte("ok\n"))
+	})
+
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(c.Status()); err != nil {
+			c.log.Errorf("Could not encode status: %s", err)
+		}
+	})
+
+	mux.Handle("/metrics", c.MetricsHandler())
+
+	return mux
+}
+
+// startHealth serves the health endpoints on addr until Close.
+func (c *DefaultWorker) startHealth(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	c.health = &http.Server{
+		Handler: c.HealthHandler(),
+	}
+
+	c.healthListener = l
+
+	c.log.Infof("Serving health endpoints on %s", l.Addr())
+
+	go func() {
+		if err := c.health.Serve(l); err != nil && err != http.ErrServerClosed {
+			c.log.Errorf("Could not serve health endpoints: %s", err)
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package ravenworker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dutchsec/raven-worker/workflow"
+	context "golang.org/x/net/context"
+)
+
+func TestHealthHandler(t *testing.T) {
+	srvr, err := testServer(&workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	if _, err := w.Produce(context.Background(), TestProduceMessage); err != nil {
+		t.Fatalf("Could not produce message: %s", err.Error())
+	}
+
+	h := w.HealthHandler()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	if rec := get("/healthz"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected /healthz to return 200, got %d", rec.Code)
+	}
+
+	if rec := get("/readyz"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected /readyz to return 200, got %d", rec.Code)
+	}
+
+	var status Status
+	if err := json.Unmarshal(get("/status").Body.Bytes(), &status); err != nil {
+		t.Fatalf("Could not decode status: %s", err)
+	}
+
+	if !status.Connected {
+		t.Fatalf("Expected status to be connected")
+	} else if status.Produced != 1 {
+		t.Fatalf("Expected 1 produced message, got %d", status.Produced)
+	} else if status.FlowID != flowID.String() {
+		t.Fatalf("Expected flow id %s, got %s", flowID.String(), status.FlowID)
+	}
+
+	// no activity within the threshold.
+	atomic.StoreInt64(&w.(*DefaultWorker).counters.lastActivity, time.Now().Add(-time.Hour).UnixNano())
+
+	if rec := get("/readyz"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected /readyz to return 503, got %d", rec.Code)
+	}
+
+	w.Close()
+
+	if rec := get("/healthz"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected /healthz to return 200 after close, got %d", rec.Code)
+	}
+
+	if w.Status().Connected {
+		t.Fatalf("Expected status to be disconnected after close")
+	}
+}
+
+func TestWithHealthAddr(t *testing.T) {
+	srvr, err := testServer(&workflowServer{})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithHealthAddr("127.0.0.1:0"),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	addr := w.(*DefaultWorker).healthListener.Addr()
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/healthz", addr))
+	if err != nil {
+		t.Fatalf("Could not get /healthz: %s", err)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || string(body) != "ok\n" {
+		t.Fatalf("Unexpected /healthz response: %d %q", resp.StatusCode, body)
+	}
+
+	w.Close()
+
+	if _, err := http.Get(fmt.Sprintf("http://%s/healthz", addr)); err == nil {
+		t.Fatalf("Expected health endpoints to be closed")
+	}
+}
+
+func TestStatusWhileDialing(t *testing.T) {
+	dialing := make(chan struct{})
+
+	w, err := New(
+		MustWithRavenURL("capnproto://raven.internal:8023"),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithLazyConnect(),
+		WithBackOff(StopBackOff),
+		WithDialer(func(ctx context.Context, u *url.URL) (net.Conn, error) {
+			close(dialing)
+
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		w.Produce(ctx, TestProduceMessage)
+	}()
+
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	<-dialing
+
+	status := make(chan Status, 1)
+
+	go func() {
+		status <- w.Status()
+	}()
+
+	select {
+	case s := <-status:
+		if s.Connected {
+			t.Fatalf("Expected status to be disconnected while dialing")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Status blocked on the dial")
+	}
+}
@@ -0,0 +1,164 @@
+package ravenworker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/dutchsec/raven-worker/workflow"
+	"github.com/gofrs/uuid"
+)
+
+// ExtendLease tells the server the message for ref is still being
+// handled, so it will not be handed out to another worker. It returns
+// the time the server will hold the message.
+//
+//     lease, err := w.ExtendLease(ctx, ref)
+//     if errors.Is(err, ErrLeaseLost) {
+//         // another worker may handle the message
+//     }
+//
+// The context will cancel the request and any retries.
+func (c *DefaultWorker) ExtendLease(ctx context.Context, ref Reference) (time.Duration, error) {
+	ackID, err := uuid.FromString(ref.AckID)
+	if err != nil {
+		return 0, &Error{Kind: ErrInvalidReference, Err: err}
+	}
+
+	var t *time.Timer
+
+	cb := c.newBackOff()
+
+	log := c.log.With(Field{"event_id", ref.EventID}, Field{"ack_id", ref.AckID})
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+
+		sctx, span := c.startSpan(ctx, "raven.extend_lease")
+		span.set("event_id", ref.EventID)
+
+		lease, err := c.extendLease(sctx, ackID)
+		c.metrics.extend.done(start, err)
+		span.end(err)
+
+		if err == nil {
+			return lease, nil
+		}
+
+		// the lease will not come back.
+		if errors.Is(err, ErrLeaseLost) || permanent(err) {
+			return 0, err
+		}
+
+		next := cb.NextBackOff()
+		if next == backoff.Stop {
+			log.Errorw("Could not extend lease", "error", err, "attempt", attempt)
+			return 0, err
+		}
+
+		c.metrics.extend.retry(next)
+
+		if t != nil {
+			t.Reset(next)
+		} else {
+			t = time.NewTimer(next)
+			defer t.Stop()
+		}
+
+		log.Debugw("Got error while extending lease, will retry", "error", err, "attempt", attempt, "retry_in", next)
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (c *DefaultWorker) extendLease(ctx context.Context, ackID uuid.UUID) (time.Duration, error) {
+	conn, err := c.connection(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := conn.ExtendJob(ctx, func(params workflow.Connection_extendJob_Params) error {
+		return params.SetAckID(ackID.Bytes())
+	}).Struct()
+
+	if err != nil {
+		return 0, c.rpcError(err)
+	}
+
+	if !res.Extended() {
+		return 0, &Error{Kind: ErrLeaseLost}
+	}
+
+	return time.Duration(res.Lease()) * time.Millisecond, nil
+}
+
+// minKeepAlive is the shortest interval KeepAlive extends a lease.
+const minKeepAlive = 10 * time.Millisecond
+
+// KeepAlive extends the lease of ref in the background every interval,
+// or sooner when the server holds the message for less than twice the
+// interval, until cancel is called. Intervals shorter than 10ms are
+// raised to 10ms.
+//
+//     ctx, cancel := w.KeepAlive(ctx, ref, 30*time.Second)
+//     defer cancel()
+//
+// The returned context is canceled when the lease could not be
+// extended, the message may be handed out to another worker then.
+// Run does this for every message when WithKeepAlive is set.
+func (c *DefaultWorker) KeepAlive(ctx context.Context, ref Reference, interval time.Duration) (context.Context, context.CancelFunc) {
+	if interval < minKeepAlive {
+		interval = minKeepAlive
+	}
+
+	leaseCtx, cancel := context.WithCancel(ctx)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		t := time.NewTimer(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-leaseCtx.Done():
+				return
+			case <-t.C:
+			}
+
+			lease, err := c.ExtendLease(leaseCtx, ref)
+			if leaseCtx.Err() != nil {
+				return
+			} else if err != nil {
+				c.log.Errorw("Could not extend lease of message", "event_id", ref.EventID, "ack_id", ref.AckID, "error", err)
+				cancel()
+				return
+			}
+
+			c.log.Debugw("Extended lease of message", "event_id", ref.EventID, "ack_id", ref.AckID, "lease", lease)
+
+			next := interval
+			if lease > 0 && lease/2 < next {
+				next = lease / 2
+			}
+
+			if next < minKeepAlive {
+				next = minKeepAlive
+			}
+
+			t.Reset(next)
+		}
+	}()
+
+	return leaseCtx, func() {
+		cancel()
+		<-done
+	}
+}
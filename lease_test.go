@@ -0,0 +1,299 @@
+package ravenworker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/dutchsec/raven-worker/workflow"
+	"github.com/gofrs/uuid"
+	context "golang.org/x/net/context"
+)
+
+func TestExtendLease(t *testing.T) {
+	ackID, _ := uuid.NewV4()
+
+	srvr, err := testServer(&workflowServer{
+		extendJob: func(extendJob workflow.Connection_extendJob) error {
+			if v, err := extendJob.Params.AckID(); err != nil {
+				return err
+			} else if !bytes.Equal(ackID.Bytes(), v) {
+				return fmt.Errorf("Incorrect ackID")
+			}
+
+			extendJob.Results.SetExtended(true)
+			extendJob.Results.SetLease(30000)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	lease, err := w.ExtendLease(context.Background(), Reference{AckID: ackID.String()})
+	if err != nil {
+		t.Fatalf("Error extending lease: %s", err.Error())
+	}
+
+	if lease != 30*time.Second {
+		t.Fatalf("Expected lease of 30s, got %v", lease)
+	}
+}
+
+func TestExtendLeaseLost(t *testing.T) {
+	ackID, _ := uuid.NewV4()
+
+	var calls int32
+
+	srvr, err := testServer(&workflowServer{
+		extendJob: func(extendJob workflow.Connection_extendJob) error {
+			atomic.AddInt32(&calls, 1)
+
+			extendJob.Results.SetExtended(false)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 5)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	_, err = w.ExtendLease(context.Background(), Reference{AckID: ackID.String()})
+	if !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("Expected ErrLeaseLost, got %v", err)
+	}
+
+	// a lost lease should not be retried.
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("Expected 1 call, got %d", n)
+	}
+}
+
+func TestKeepAliveZeroInterval(t *testing.T) {
+	ackID, _ := uuid.NewV4()
+
+	var extends int32
+
+	srvr, err := testServer(&workflowServer{
+		extendJob: func(extendJob workflow.Connection_extendJob) error {
+			atomic.AddInt32(&extends, 1)
+
+			extendJob.Results.SetExtended(true)
+			extendJob.Results.SetLease(0)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	_, cancel := w.KeepAlive(context.Background(), Reference{AckID: ackID.String()}, 0)
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	// the interval is raised to the minimum of 10ms.
+	if n := atomic.LoadInt32(&extends); n < 1 || n > 10 {
+		t.Fatalf("Expected the lease to be extended up to 10 times, got %d", n)
+	}
+}
+
+func TestRunKeepAlive(t *testing.T) {
+	ackID, _ := uuid.NewV4()
+	eventID, _ := uuid.NewV4()
+
+	var jobs, extends, acks int32
+
+	srvr, err := testServer(&workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			if atomic.AddInt32(&jobs, 1) > 1 {
+				return errors.New("item not found")
+			}
+
+			getJob.Results.SetAckID(ackID.Bytes())
+			getJob.Results.SetEventID(eventID.Bytes())
+			return nil
+		},
+		getEvent: func(getEvent workflow.Connection_getEvent) error {
+			evt, err := getEvent.Results.NewEvent()
+			if err != nil {
+				return err
+			}
+
+			return getEvent.Results.SetEvent(evt)
+		},
+		extendJob: func(extendJob workflow.Connection_extendJob) error {
+			atomic.AddInt32(&extends, 1)
+
+			extendJob.Results.SetExtended(true)
+			extendJob.Results.SetLease(1000)
+			return nil
+		},
+		ackJob: func(ackJob workflow.Connection_ackJob) error {
+			atomic.AddInt32(&acks, 1)
+
+			ackJob.Results.SetAcked(true)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		MustWithConsumeTimeout("100ms"),
+		MustWithKeepAlive("10ms"),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	err = w.Run(context.Background(), func(ctx context.Context, message Message) (Result, error) {
+		time.Sleep(100 * time.Millisecond)
+		return Result{}, nil
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %s", err.Error())
+	}
+
+	if n := atomic.LoadInt32(&extends); n < 2 {
+		t.Fatalf("Expected lease to be extended at least twice, got %d", n)
+	}
+
+	if n := atomic.LoadInt32(&acks); n != 1 {
+		t.Fatalf("Expected 1 ack, got %d", n)
+	}
+}
+
+func TestRunKeepAliveLost(t *testing.T) {
+	ackID, _ := uuid.NewV4()
+	eventID, _ := uuid.NewV4()
+
+	var jobs, acks int32
+
+	srvr, err := testServer(&workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			if atomic.AddInt32(&jobs, 1) > 1 {
+				return errors.New("item not found")
+			}
+
+			getJob.Results.SetAckID(ackID.Bytes())
+			getJob.Results.SetEventID(eventID.Bytes())
+			return nil
+		},
+		getEvent: func(getEvent workflow.Connection_getEvent) error {
+			evt, err := getEvent.Results.NewEvent()
+			if err != nil {
+				return err
+			}
+
+			return getEvent.Results.SetEvent(evt)
+		},
+		extendJob: func(extendJob workflow.Connection_extendJob) error {
+			extendJob.Results.SetExtended(false)
+			return nil
+		},
+		ackJob: func(ackJob workflow.Connection_ackJob) error {
+			atomic.AddInt32(&acks, 1)
+
+			ackJob.Results.SetAcked(true)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		MustWithConsumeTimeout("100ms"),
+		MustWithKeepAlive("10ms"),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	err = w.Run(context.Background(), func(ctx context.Context, message Message) (Result, error) {
+		select {
+		case <-ctx.Done():
+			return Result{}, ctx.Err()
+		case <-time.After(time.Second):
+			return Result{}, errors.New("handler context not canceled")
+		}
+	})
+	if err != nil {
+		t.Fatalf("Run returned error: %s", err.Error())
+	}
+
+	if n := atomic.LoadInt32(&acks); n != 0 {
+		t.Fatalf("Expected no ack after losing the lease, got %d", n)
+	}
+}
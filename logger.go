@@ -3,6 +3,10 @@ package ravenworker
 import (
 	"fmt"
 	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
 
 	"gitlab.com/z0mbie42/rz-go/v2"
 	context "golang.org/x/net/context"
@@ -21,6 +25,197 @@ type defaultLogger struct {
 	rz.Logger
 
 	LogCloser // need this for closing the logger.
+
+	workerID string // logged as worker_id on every line, if set.
+}
+
+// Field is a key-value pair attached to structured log lines.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// FieldLogger is a Logger with structured fields. With returns a logger
+// adding fields to every line, the *w methods take key-value pairs.
+//
+//     log := NewFieldLogger(DefaultLogger).With(Field{"flow_id", flowID})
+//     log.Infow("Handled message", "event_id", ref.EventID, "attempt", 2)
+type FieldLogger interface {
+	Logger
+
+	With(fields ...Field) FieldLogger
+
+	Debugw(msg string, keyvals ...interface{})
+	Infow(msg string, keyvals ...interface{})
+	Errorw(msg string, keyvals ...interface{})
+}
+
+// Fields returns the fields of the key-value pairs. A Field is used as
+// is, a key without value is logged with key '!BADKEY', like log/slog.
+func Fields(keyvals ...interface{}) []Field {
+	fields := make([]Field, 0, len(keyvals)/2)
+
+	for len(keyvals) > 0 {
+		switch v := keyvals[0].(type) {
+		case Field:
+			fields = append(fields, v)
+			keyvals = keyvals[1:]
+		case string:
+			if len(keyvals) == 1 {
+				fields = append(fields, Field{"!BADKEY", v})
+				keyvals = keyvals[1:]
+			} else {
+				fields = append(fields, Field{v, keyvals[1]})
+				keyvals = keyvals[2:]
+			}
+		default:
+			fields = append(fields, Field{"!BADKEY", v})
+			keyvals = keyvals[1:]
+		}
+	}
+
+	return fields
+}
+
+// NewFieldLogger returns l as a FieldLogger. Loggers that only
+// implement Logger get the fields appended to the message as
+// key=value pairs.
+func NewFieldLogger(l Logger) FieldLogger {
+	if fl, ok := l.(FieldLogger); ok {
+		return fl
+	}
+
+	return &printfLogger{Logger: l}
+}
+
+// printfLogger adds structured fields to a Logger.
+type printfLogger struct {
+	Logger
+
+	fields []Field
+}
+
+func (l *printfLogger) With(fields ...Field) FieldLogger {
+	return &printfLogger{
+		Logger: l.Logger,
+		fields: append(append([]Field{}, l.fields...), fields...),
+	}
+}
+
+func (l *printfLogger) Debugf(msg string, args ...interface{}) {
+	l.Logger.Debugf("%s", l.format(fmt.Sprintf(msg, args...), nil))
+}
+
+func (l *printfLogger) Infof(msg string, args ...interface{}) {
+	l.Logger.Infof("%s", l.format(fmt.Sprintf(msg, args...), nil))
+}
+
+func (l *printfLogger) Errorf(msg string, args ...interface{}) {
+	l.Logger.Errorf("%s", l.format(fmt.Sprintf(msg, args...), nil))
+}
+
+func (l *printfLogger) Fatalf(msg string, args ...interface{}) {
+	l.Logger.Fatalf("%s", l.format(fmt.Sprintf(msg, args...), nil))
+}
+
+func (l *printfLogger) Debugw(msg string, keyvals ...interface{}) {
+	l.Logger.Debugf("%s", l.format(msg, Fields(keyvals...)))
+}
+
+func (l *printfLogger) Infow(msg string, keyvals ...interface{}) {
+	l.Logger.Infof("%s", l.format(msg, Fields(keyvals...)))
+}
+
+func (l *printfLogger) Errorw(msg string, keyvals ...interface{}) {
+	l.Logger.Errorf("%s", l.format(msg, Fields(keyvals...)))
+}
+
+// format appends the fields of the logger and fields to msg.
+func (l *printfLogger) format(msg string, fields []Field) string {
+	var sb strings.Builder
+
+	sb.WriteString(msg)
+
+	for _, f := range append(append([]Field{}, l.fields...), fields...) {
+		value := fmt.Sprint(f.Value)
+		if value == "" || strings.ContainsAny(value, " =\"\n") {
+			value = strconv.Quote(value)
+		}
+
+		fmt.Fprintf(&sb, " %s=%s", f.Key, value)
+	}
+
+	return sb.String()
+}
+
+type loggerKey struct{}
+
+// ContextWithLogger returns a copy of ctx carrying l.
+func ContextWithLogger(ctx context.Context, l FieldLogger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LoggerFromContext returns the logger of ctx, or DefaultLogger. Run
+// passes a logger bound to the message to the handler, adding the
+// event_id, ack_id, flow_id, worker_id and the elapsed time since the
+// message was consumed to every line.
+//
+//     func handler(ctx context.Context, message Message) (Result, error) {
+//         log := LoggerFromContext(ctx)
+//         log.Infow("Transforming message", "size", len(message.Content))
+//     }
+func LoggerFromContext(ctx context.Context) FieldLogger {
+	if l, ok := ctx.Value(loggerKey{}).(FieldLogger); ok {
+		return l
+	}
+
+	return DefaultLogger
+}
+
+// elapsedLogger adds the time elapsed since start to every line.
+type elapsedLogger struct {
+	FieldLogger
+
+	start time.Time
+}
+
+func (l *elapsedLogger) elapsed() FieldLogger {
+	return l.FieldLogger.With(Field{"elapsed", time.Since(l.start)})
+}
+
+func (l *elapsedLogger) With(fields ...Field) FieldLogger {
+	return &elapsedLogger{
+		FieldLogger: l.FieldLogger.With(fields...),
+		start:       l.start,
+	}
+}
+
+func (l *elapsedLogger) Debugf(msg string, args ...interface{}) {
+	l.elapsed().Debugf(msg, args...)
+}
+
+func (l *elapsedLogger) Infof(msg string, args ...interface{}) {
+	l.elapsed().Infof(msg, args...)
+}
+
+func (l *elapsedLogger) Errorf(msg string, args ...interface{}) {
+	l.elapsed().Errorf(msg, args...)
+}
+
+func (l *elapsedLogger) Fatalf(msg string, args ...interface{}) {
+	l.elapsed().Fatalf(msg, args...)
+}
+
+func (l *elapsedLogger) Debugw(msg string, keyvals ...interface{}) {
+	l.elapsed().Debugw(msg, keyvals...)
+}
+
+func (l *elapsedLogger) Infow(msg string, keyvals ...interface{}) {
+	l.elapsed().Infow(msg, keyvals...)
+}
+
+func (l *elapsedLogger) Errorw(msg string, keyvals ...interface{}) {
+	l.elapsed().Errorw(msg, keyvals...)
 }
 
 type LogCloser interface {
@@ -36,10 +231,17 @@ func (NullLogCloser) Close() error {
 
 //NewDefaultLogger creates a JSON logger which outputs to an http endpoint.
 //provide an empty string as endpoint to log to stdout.
+//The id is logged as worker_id on every line, New won't add it again.
 func NewDefaultLogger(endpoint, id string) *defaultLogger {
-	// TODO: add flow_id, worker_id and block_id
+	fields := []rz.Field{rz.Timestamp(true)}
+
+	// TODO: add block_id, New adds the flow_id.
+	if id != "" {
+		fields = append(fields, rz.String("worker_id", id))
+	}
+
 	logger := rz.New(
-		rz.Fields(rz.Timestamp(true), rz.String("worker-id", id)),
+		rz.Fields(fields...),
 	)
 
 	var logCloser LogCloser = &NullLogCloser{}
@@ -67,25 +269,77 @@ func NewDefaultLogger(endpoint, id string) *defaultLogger {
 	return &defaultLogger{
 		Logger:    logger,
 		LogCloser: logCloser,
+		workerID:  id,
 	}
 }
 
-// TODO: improving logging
+// Infof logs the formatted message at info level.
 func (l *defaultLogger) Infof(msg string, args ...interface{}) {
 	l.Info(fmt.Sprintf(msg, args...))
 }
 
-// TODO: improving logging
+// Debugf logs the formatted message at debug level.
 func (l *defaultLogger) Debugf(msg string, args ...interface{}) {
 	l.Debug(fmt.Sprintf(msg, args...))
 }
 
-// TODO: improving logging
+// Errorf logs the formatted message at error level.
 func (l *defaultLogger) Errorf(msg string, args ...interface{}) {
 	l.Error(fmt.Sprintf(msg, args...))
 }
 
 func (l *defaultLogger) Fatalf(msg string, args ...interface{}) {
-	//TODO (jerry 2019-10-23): Add stacktrace
-	l.Fatal(fmt.Sprintf(msg, args...))
+	l.Fatal(fmt.Sprintf(msg, args...), rz.String("stacktrace", string(debug.Stack())))
+}
+
+// With returns a logger adding fields to every line, sharing the
+// log uploader.
+func (l *defaultLogger) With(fields ...Field) FieldLogger {
+	return &defaultLogger{
+		Logger:    l.Logger.With(rz.Fields(rzFields(fields)...)),
+		LogCloser: l.LogCloser,
+		workerID:  l.workerID,
+	}
+}
+
+// Debugw logs msg with the key-value pairs at debug level.
+func (l *defaultLogger) Debugw(msg string, keyvals ...interface{}) {
+	l.Debug(msg, rzFields(Fields(keyvals...))...)
+}
+
+// Infow logs msg with the key-value pairs at info level.
+func (l *defaultLogger) Infow(msg string, keyvals ...interface{}) {
+	l.Info(msg, rzFields(Fields(keyvals...))...)
+}
+
+// Errorw logs msg with the key-value pairs at error level.
+func (l *defaultLogger) Errorw(msg string, keyvals ...interface{}) {
+	l.Error(msg, rzFields(Fields(keyvals...))...)
+}
+
+// RzLogger returns a FieldLogger writing to the rz logger l.
+func RzLogger(l rz.Logger) FieldLogger {
+	return &defaultLogger{
+		Logger:    l,
+		LogCloser: &NullLogCloser{},
+	}
+}
+
+// rzFields converts fields to rz fields, errors and values with a
+// String method are logged as strings.
+func rzFields(fields []Field) []rz.Field {
+	result := make([]rz.Field, len(fields))
+
+	for i, f := range fields {
+		switch v := f.Value.(type) {
+		case error:
+			result[i] = rz.String(f.Key, v.Error())
+		case fmt.Stringer:
+			result[i] = rz.String(f.Key, v.String())
+		default:
+			result[i] = rz.Any(f.Key, v)
+		}
+	}
+
+	return result
 }
@@ -2,12 +2,18 @@ package ravenworker
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"gitlab.com/z0mbie42/rz-go/v2"
+	context "golang.org/x/net/context"
 )
 
 func TestNewDefaultLogger(t *testing.T) {
@@ -18,7 +24,7 @@ func TestNewDefaultLogger(t *testing.T) {
 		t.Fatal("could not initialize defaultlogger. returned <nil>")
 	}
 
-	if deflog.upload == nil {
+	if _, ok := deflog.LogCloser.(*logUploader); !ok {
 		t.Fatal("could not initialize logUploader. returned <nil>")
 	}
 }
@@ -98,3 +104,179 @@ func TestLevelFatal(t *testing.T) {
 	}
 	t.Fatalf("process ran with err %v, want exit status 1", err)
 }
+
+func TestFields(t *testing.T) {
+	fields := Fields("a", 1, Field{"b", 2}, 3, "c")
+
+	want := []Field{{"a", 1}, {"b", 2}, {"!BADKEY", 3}, {"!BADKEY", "c"}}
+
+	if len(fields) != len(want) {
+		t.Fatalf("Expected %d fields, got %v", len(want), fields)
+	}
+
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Errorf("Expected field %v, got %v", want[i], fields[i])
+		}
+	}
+}
+
+func TestDefaultLoggerWith(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	l := &defaultLogger{Logger: rz.New(rz.Writer(buf))}
+
+	l.With(Field{"flow_id", "f"}).Infow("A", "event_id", "e", "error", errors.New("B"))
+
+	for _, want := range []string{`"flow_id":"f"`, `"event_id":"e"`, `"error":"B"`, `"message":"A"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("did not find %s in output: %s", want, buf.String())
+		}
+	}
+}
+
+func TestDefaultLoggerWorkerID(t *testing.T) {
+	for _, id := range []string{"", workerID.String()} {
+		r, pw, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the default logger writes to stdout.
+		stdout := os.Stdout
+		os.Stdout = pw
+		l := NewDefaultLogger("", id)
+		os.Stdout = stdout
+
+		// outside of a worker.
+		l.Infof("A")
+
+		w, err := New(
+			MustWithRavenURL("capnproto://127.0.0.1:8023"),
+			MustWithFlowID(flowID.String()),
+			MustWithWorkerID(workerID.String()),
+			MustWithLogger(l),
+			WithLazyConnect(),
+		)
+		if err != nil {
+			t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+		}
+
+		w.(*DefaultWorker).log.Infof("B")
+		w.Close()
+		pw.Close()
+
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected 2 lines, got: %s", data)
+		}
+
+		if n := strings.Count(lines[0], "worker_id"); id != "" && n != 1 {
+			t.Fatalf("Expected worker_id in line of the logger: %s", lines[0])
+		} else if id == "" && n != 0 {
+			t.Fatalf("Expected no worker_id in line of the logger: %s", lines[0])
+		}
+
+		if n := strings.Count(lines[1], "worker_id"); n != 1 {
+			t.Fatalf("Expected worker_id once in line of the worker, got %d: %s", n, lines[1])
+		} else if !strings.Contains(lines[1], workerID.String()) {
+			t.Fatalf("Expected worker id %s in line of the worker: %s", workerID.String(), lines[1])
+		}
+	}
+}
+
+// printfRecorder is a Logger recording the formatted lines.
+type printfRecorder struct {
+	m     sync.Mutex
+	lines []string
+}
+
+func (r *printfRecorder) record(msg string, args ...interface{}) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.lines = append(r.lines, fmt.Sprintf(msg, args...))
+}
+
+// find returns the first line containing s.
+func (r *printfRecorder) find(s string) (string, bool) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	for _, line := range r.lines {
+		if strings.Contains(line, s) {
+			return line, true
+		}
+	}
+
+	return "", false
+}
+
+func (r *printfRecorder) Debugf(msg string, args ...interface{}) {
+	r.record(msg, args...)
+}
+
+func (r *printfRecorder) Infof(msg string, args ...interface{}) {
+	r.record(msg, args...)
+}
+
+func (r *printfRecorder) Errorf(msg string, args ...interface{}) {
+	r.record(msg, args...)
+}
+
+func (r *printfRecorder) Fatalf(msg string, args ...interface{}) {
+	r.record(msg, args...)
+}
+
+func TestNewFieldLogger(t *testing.T) {
+	r := &printfRecorder{}
+
+	l := NewFieldLogger(r).With(Field{"flow_id", "f"})
+
+	l.Infof("A%s", "B")
+	l.Errorw("C", "error", errors.New("not found"), "attempt", 2)
+
+	want := []string{
+		"AB flow_id=f",
+		`C flow_id=f error="not found" attempt=2`,
+	}
+
+	if len(r.lines) != len(want) {
+		t.Fatalf("Expected %d lines, got %v", len(want), r.lines)
+	}
+
+	for i := range want {
+		if r.lines[i] != want[i] {
+			t.Errorf("Expected line %s, got %s", want[i], r.lines[i])
+		}
+	}
+
+	deflog := &defaultLogger{}
+	if NewFieldLogger(deflog) != FieldLogger(deflog) {
+		t.Fatalf("Expected FieldLogger to be used as is")
+	}
+}
+
+func TestLoggerFromContext(t *testing.T) {
+	if l := LoggerFromContext(context.Background()); l != FieldLogger(DefaultLogger) {
+		t.Fatalf("Expected DefaultLogger without logger in context")
+	}
+
+	r := &printfRecorder{}
+
+	ctx := ContextWithLogger(context.Background(), &elapsedLogger{
+		FieldLogger: NewFieldLogger(r).With(Field{"event_id", "e"}),
+		start:       time.Now(),
+	})
+
+	LoggerFromContext(ctx).Infow("A")
+
+	if line, ok := r.find("A event_id=e elapsed="); !ok {
+		t.Fatalf("Expected line with event_id and elapsed, got %v %v", line, r.lines)
+	}
+}
@@ -23,6 +23,8 @@ type logUploader struct {
 
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
+
+	metrics *opMetrics
 }
 
 func NewLogUploader(ctx context.Context, endpoint string) (*logUploader, error) {
@@ -35,6 +37,7 @@ func NewLogUploader(ctx context.Context, endpoint string) (*logUploader, error)
 	l := &logUploader{
 		endpoint: endpoint,
 		cancel:   cancel,
+		metrics:  newOpMetrics(true),
 	}
 
 	// start periodic uploading of log messages.
@@ -97,18 +100,37 @@ func (l *logUploader) send() {
 
 	// post the collected logs.
 
-	err := backoff.Retry(func() error {
-		resp, err := http.Post(l.endpoint, "application/json", bytes.NewBuffer(body))
-		if err == nil {
-			if resp.StatusCode != 200 {
-				return errors.New(resp.Status)
-			}
-			defer resp.Body.Close()
-		}
+	err := backoff.RetryNotify(func() error {
+		start := time.Now()
+
+		err := l.post(body)
+		l.metrics.done(start, err)
+
 		return err
-	}, bpost)
+	}, bpost, func(err error, next time.Duration) {
+		l.metrics.retry(next)
+	})
+
 	if err != nil {
 		// dump logs to stdout.
 		fmt.Printf("Upload Error: %v\n%s\n", err, string(body))
+	} else {
+		l.metrics.message(body)
+	}
+}
+
+// post the body to the endpoint.
+func (l *logUploader) post(body []byte) error {
+	resp, err := http.Post(l.endpoint, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return errors.New(resp.Status)
 	}
+
+	return nil
 }
@@ -0,0 +1,256 @@
+package ravenworker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// latencyBuckets in seconds.
+	latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+	// backOffBuckets in seconds.
+	backOffBuckets = []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60}
+
+	// sizeBuckets in bytes.
+	sizeBuckets = []float64{256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20}
+)
+
+// histogram counts observations in cumulative buckets, like a
+// Prometheus histogram.
+type histogram struct {
+	buckets []float64
+
+	m      sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+func (h *histogram) observe(v float64) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	for i, le := range h.buckets {
+		if v <= le {
+			h.counts[i]++
+		}
+	}
+
+	h.sum += v
+	h.count++
+}
+
+// write writes the samples of the histogram in Prometheus text format.
+func (h *histogram) write(w io.Writer, name, labels string) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	for i, le := range h.buckets {
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(le), h.counts[i])
+	}
+
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+// opMetrics are the metrics of a single operation.
+type opMetrics struct {
+	calls   int64
+	errors  int64
+	retries int64
+
+	latency *histogram
+	backOff *histogram
+	size    *histogram // nil for operations without messages.
+}
+
+func newOpMetrics(withSize bool) *opMetrics {
+	m := &opMetrics{
+		latency: newHistogram(latencyBuckets),
+		backOff: newHistogram(backOffBuckets),
+	}
+
+	if withSize {
+		m.size = newHistogram(sizeBuckets)
+	}
+
+	return m
+}
+
+// done records a call that started at start.
+func (m *opMetrics) done(start time.Time, err error) {
+	atomic.AddInt64(&m.calls, 1)
+
+	if err != nil {
+		atomic.AddInt64(&m.errors, 1)
+	}
+
+	m.latency.observe(time.Since(start).Seconds())
+}
+
+// retry records a retry after waiting next.
+func (m *opMetrics) retry(next time.Duration) {
+	atomic.AddInt64(&m.retries, 1)
+
+	m.backOff.observe(next.Seconds())
+}
+
+// message records the size of a message.
+func (m *opMetrics) message(content []byte) {
+	if m.size != nil {
+		m.size.observe(float64(len(content)))
+	}
+}
+
+// metrics of the worker.
+type metrics struct {
+	consume *opMetrics
+	get     *opMetrics
+	ack     *opMetrics
+	nack    *opMetrics
+	extend  *opMetrics
+	produce *opMetrics
+
+	filtered int64 // acks with WithFilter.
+	inFlight int64 // messages being handled by Run.
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		consume: newOpMetrics(false),
+		get:     newOpMetrics(true),
+		ack:     newOpMetrics(true),
+		nack:    newOpMetrics(false),
+		extend:  newOpMetrics(false),
+		produce: newOpMetrics(true),
+	}
+}
+
+// MetricsHandler returns the handler serving the metrics of the worker
+// in Prometheus text format, labelled with flow_id and worker_id. It is
+// served on /metrics of the health endpoints as well.
+//
+//     http.Handle("/metrics", w.MetricsHandler())
+func (c *DefaultWorker) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		bw := bufio.NewWriter(w)
+		c.writeMetrics(bw)
+		bw.Flush()
+	})
+}
+
+// writeMetrics writes all metrics in Prometheus text format.
+func (c *DefaultWorker) writeMetrics(w io.Writer) {
+	labels := fmt.Sprintf("flow_id=\"%s\",worker_id=\"%s\"", escapeLabel(c.FlowID.String()), escapeLabel(c.WorkerID.String()))
+
+	type op struct {
+		name string
+		m    *opMetrics
+	}
+
+	ops := []op{
+		{"consume", c.metrics.consume},
+		{"get", c.metrics.get},
+		{"ack", c.metrics.ack},
+		{"nack", c.metrics.nack},
+		{"extend_lease", c.metrics.extend},
+		{"produce", c.metrics.produce},
+	}
+
+	for _, u := range c.uploaders() {
+		ops = append(ops, op{"log_upload", u.metrics})
+	}
+
+	writeCounter := func(name, help string, value func(m *opMetrics) int64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+
+		for _, op := range ops {
+			fmt.Fprintf(w, "%s{%s,op=\"%s\"} %d\n", name, labels, op.name, value(op.m))
+		}
+	}
+
+	writeCounter("raven_worker_calls_total", "Calls to the Raven server.", func(m *opMetrics) int64 { return atomic.LoadInt64(&m.calls) })
+	writeCounter("raven_worker_errors_total", "Calls to the Raven server that failed.", func(m *opMetrics) int64 { return atomic.LoadInt64(&m.errors) })
+	writeCounter("raven_worker_retries_total", "Calls to the Raven server that have been retried.", func(m *opMetrics) int64 { return atomic.LoadInt64(&m.retries) })
+
+	fmt.Fprintf(w, "# HELP raven_worker_filtered_acks_total Messages acknowledged with a filter.\n# TYPE raven_worker_filtered_acks_total counter\n")
+	fmt.Fprintf(w, "raven_worker_filtered_acks_total{%s} %d\n", labels, atomic.LoadInt64(&c.metrics.filtered))
+
+	writeHistogram := func(name, help string, h func(m *opMetrics) *histogram) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+
+		for _, op := range ops {
+			if h(op.m) != nil {
+				h(op.m).write(w, name, fmt.Sprintf("%s,op=\"%s\"", labels, op.name))
+			}
+		}
+	}
+
+	writeHistogram("raven_worker_rpc_duration_seconds", "Latency of calls to the Raven server.", func(m *opMetrics) *histogram { return m.latency })
+	writeHistogram("raven_worker_backoff_seconds", "Time waited before retrying a call.", func(m *opMetrics) *histogram { return m.backOff })
+	writeHistogram("raven_worker_message_size_bytes", "Size of the message content.", func(m *opMetrics) *histogram { return m.size })
+
+	fmt.Fprintf(w, "# HELP raven_worker_in_flight Messages being handled.\n# TYPE raven_worker_in_flight gauge\n")
+	fmt.Fprintf(w, "raven_worker_in_flight{%s} %d\n", labels, atomic.LoadInt64(&c.metrics.inFlight))
+
+	status := c.Status()
+
+	connected := 0
+	if status.Connected {
+		connected = 1
+	}
+
+	fmt.Fprintf(w, "# HELP raven_worker_connected Connected to the Raven server at url.\n# TYPE raven_worker_connected gauge\n")
+	fmt.Fprintf(w, "raven_worker_connected{%s,url=\"%s\"} %d\n", labels, escapeLabel(status.URL), connected)
+}
+
+// uploaders returns the log uploaders of the worker.
+func (c *DefaultWorker) uploaders() []*logUploader {
+	uploaders := []*logUploader{}
+
+	for _, closer := range c.closers {
+		switch v := closer.(type) {
+		case *logUploader:
+			uploaders = append(uploaders, v)
+		case *defaultLogger:
+			if u, ok := v.LogCloser.(*logUploader); ok {
+				uploaders = append(uploaders, u)
+			}
+		}
+	}
+
+	return uploaders
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
@@ -0,0 +1,100 @@
+package ravenworker
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/dutchsec/raven-worker/workflow"
+	"github.com/gofrs/uuid"
+	context "golang.org/x/net/context"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	ackID, _ := uuid.NewV4()
+	eventID, _ := uuid.NewV4()
+
+	var ackCalls int
+
+	srvr, err := testServer(&workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			getJob.Results.SetAckID(ackID.Bytes())
+			getJob.Results.SetEventID(eventID.Bytes())
+			return nil
+		},
+		ackJob: func(ackJob workflow.Connection_ackJob) error {
+			ackCalls++
+
+			// fail the first call, so the ack is retried.
+			if ackCalls == 1 {
+				return fmt.Errorf("try again")
+			}
+
+			ackJob.Results.SetAcked(true)
+			return nil
+		},
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	ref, err := w.Consume(context.Background())
+	if err != nil {
+		t.Fatalf("Could not consume message: %s", err.Error())
+	}
+
+	if err := w.Ack(context.Background(), ref, WithFilter()); err != nil {
+		t.Fatalf("Ack failed: %s", err.Error())
+	}
+
+	if _, err := w.Produce(context.Background(), TestProduceMessage); err != nil {
+		t.Fatalf("Could not produce message: %s", err.Error())
+	}
+
+	rec := httptest.NewRecorder()
+	w.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	labels := fmt.Sprintf(`flow_id="%s",worker_id="%s"`, flowID.String(), workerID.String())
+
+	body := rec.Body.String()
+
+	for _, sample := range []string{
+		fmt.Sprintf(`raven_worker_calls_total{%s,op="consume"} 1`, labels),
+		fmt.Sprintf(`raven_worker_calls_total{%s,op="ack"} 2`, labels),
+		fmt.Sprintf(`raven_worker_errors_total{%s,op="ack"} 1`, labels),
+		fmt.Sprintf(`raven_worker_retries_total{%s,op="ack"} 1`, labels),
+		fmt.Sprintf(`raven_worker_calls_total{%s,op="produce"} 1`, labels),
+		fmt.Sprintf(`raven_worker_filtered_acks_total{%s} 1`, labels),
+		fmt.Sprintf(`raven_worker_rpc_duration_seconds_count{%s,op="ack"} 2`, labels),
+		fmt.Sprintf(`raven_worker_backoff_seconds_count{%s,op="ack"} 1`, labels),
+		fmt.Sprintf(`raven_worker_message_size_bytes_count{%s,op="produce"} 1`, labels),
+		fmt.Sprintf(`raven_worker_in_flight{%s} 0`, labels),
+		fmt.Sprintf(`raven_worker_connected{%s,url="capnproto://%s"} 1`, labels, srvr.Addr().String()),
+	} {
+		if !strings.Contains(body, sample+"\n") {
+			t.Errorf("Expected sample %s in:\n%s", sample, body)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package ravenworker
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/dutchsec/raven-worker/workflow"
+	"github.com/gofrs/uuid"
+	context "golang.org/x/net/context"
+)
+
+type NackOptionFunc func(r *nackRequest) error
+
+// WithRequeue will hand the message out again immediately.
+func WithRequeue() NackOptionFunc {
+	return func(r *nackRequest) error {
+		r.Requeue = true
+		return nil
+	}
+}
+
+// WithRequeueDelay will hand the message out again after delay.
+func WithRequeueDelay(delay time.Duration) NackOptionFunc {
+	return func(r *nackRequest) error {
+		if delay < 0 {
+			return fmt.Errorf("requeue delay should not be negative, got %v", delay)
+		}
+		r.Requeue = true
+		r.Delay = delay
+		return nil
+	}
+}
+
+// WithReason will pass the reason of the nack to the server.
+func WithReason(reason string) NackOptionFunc {
+	return func(r *nackRequest) error {
+		r.Reason = reason
+		return nil
+	}
+}
+
+type nackRequest struct {
+	Requeue bool
+	Delay   time.Duration
+	Reason  string
+}
+
+// Nack will hand the message back to the server, only consumed messages
+// are allowed. Without options the message will not be processed again.
+//
+// WithRequeue() will hand the message out again
+// WithRequeueDelay(d) will hand the message out again after d
+// WithReason(s) will tell the server why
+//
+//     err := w.Nack(ctx, ref, WithRequeueDelay(time.Minute), WithReason(err.Error()))
+//
+// The context will cancel the request and any retries.
+func (c *DefaultWorker) Nack(ctx context.Context, ref Reference, options ...NackOptionFunc) error {
+	nr := nackRequest{}
+
+	for _, optionFn := range options {
+		if err := optionFn(&nr); err != nil {
+			return err
+		}
+	}
+
+	ackID, err := uuid.FromString(ref.AckID)
+	if err != nil {
+		return &Error{Kind: ErrInvalidReference, Err: err}
+	}
+
+	var t *time.Timer
+
+	cb := c.newBackOff()
+
+	log := c.log.With(Field{"event_id", ref.EventID}, Field{"ack_id", ref.AckID})
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+
+		sctx, span := c.startSpan(ctx, "raven.nack")
+		span.set("event_id", ref.EventID)
+
+		err := c.nack(sctx, ackID, nr)
+		c.metrics.nack.done(start, err)
+		span.end(err)
+
+		if err == nil {
+			atomic.AddInt64(&c.counters.nacked, 1)
+			return nil
+		}
+
+		// the server will not change its mind.
+		if errors.Is(err, ErrNackRejected) || permanent(err) {
+			log.Errorw("Could not nack message", "error", err, "attempt", attempt)
+			return err
+		}
+
+		next := cb.NextBackOff()
+		if next == backoff.Stop {
+			log.Errorw("Could not nack message", "error", err, "attempt", attempt)
+			return err
+		}
+
+		c.metrics.nack.retry(next)
+
+		if t != nil {
+			t.Reset(next)
+		} else {
+			t = time.NewTimer(next)
+			defer t.Stop()
+		}
+
+		log.Debugw("Got error while nack message, will retry", "error", err, "attempt", attempt, "retry_in", next)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (c *DefaultWorker) nack(ctx context.Context, ackID uuid.UUID, nr nackRequest) error {
+	conn, err := c.connection(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := conn.NackJob(ctx, func(params workflow.Connection_nackJob_Params) error {
+		params.SetRequeue(nr.Requeue)
+		params.SetDelay(uint64(nr.Delay / time.Millisecond))
+
+		if err := params.SetReason(nr.Reason); err != nil {
+			return err
+		}
+
+		return params.SetAckID(ackID.Bytes())
+	}).Struct()
+
+	if err != nil {
+		return c.rpcError(err)
+	}
+
+	if !res.Nacked() {
+		return &Error{Kind: ErrNackRejected}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package ravenworker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/dutchsec/raven-worker/workflow"
+	"github.com/gofrs/uuid"
+	context "golang.org/x/net/context"
+)
+
+func TestNack(t *testing.T) {
+	ackID, _ := uuid.NewV4()
+
+	srvr, err := testServer(&workflowServer{
+		nackJob: func(nackJob workflow.Connection_nackJob) error {
+			if v, err := nackJob.Params.AckID(); err != nil {
+				return err
+			} else if !bytes.Equal(ackID.Bytes(), v) {
+				return fmt.Errorf("Incorrect ackID")
+			}
+
+			if !nackJob.Params.Requeue() {
+				return fmt.Errorf("Expected requeue")
+			}
+
+			if v := nackJob.Params.Delay(); v != 1500 {
+				return fmt.Errorf("Incorrect delay: %d", v)
+			}
+
+			if v, err := nackJob.Params.Reason(); err != nil {
+				return err
+			} else if v != "downstream unavailable" {
+				return fmt.Errorf("Incorrect reason: %s", v)
+			}
+
+			nackJob.Results.SetNacked(true)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	err = w.Nack(context.Background(), Reference{AckID: ackID.String()},
+		WithRequeueDelay(1500*time.Millisecond),
+		WithReason("downstream unavailable"),
+	)
+	if err != nil {
+		t.Fatalf("Error nack message: %s", err.Error())
+	}
+}
+
+func TestNackRejected(t *testing.T) {
+	ackID, _ := uuid.NewV4()
+
+	var calls int32
+
+	srvr, err := testServer(&workflowServer{
+		nackJob: func(nackJob workflow.Connection_nackJob) error {
+			atomic.AddInt32(&calls, 1)
+
+			if nackJob.Params.Requeue() {
+				return fmt.Errorf("Expected no requeue")
+			}
+
+			nackJob.Results.SetNacked(false)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 5)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	err = w.Nack(context.Background(), Reference{AckID: ackID.String()})
+	if !errors.Is(err, ErrNackRejected) {
+		t.Fatalf("Expected ErrNackRejected, got %v", err)
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("Expected 1 call, got %d", n)
+	}
+}
+
+func TestNackInvalidReference(t *testing.T) {
+	w := &DefaultWorker{}
+
+	err := w.Nack(context.Background(), Reference{AckID: "invalid"}, WithRequeue())
+	if !errors.Is(err, ErrInvalidReference) {
+		t.Fatalf("Expected ErrInvalidReference, got %v", err)
+	}
+}
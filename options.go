@@ -65,7 +65,7 @@ func WithLogger(l Logger) (OptionFunc, error) {
 	}
 
 	return func(c *Config) error {
-		c.log = l
+		c.log = NewFieldLogger(l)
 
 		// use Close if available.
 		if deflog, ok := l.(io.Closer); ok {
@@ -84,6 +84,79 @@ func WithBackOff(fn BackOffFunc) OptionFunc {
 	}
 }
 
+//WithAuthToken authenticates the worker with token when connecting to
+// the Raven server. ErrUnauthorized is returned when the server rejects
+// the worker.
+func WithAuthToken(token string) OptionFunc {
+	return func(c *Config) error {
+		c.authToken = token
+		return nil
+	}
+}
+
+//WithDialer opens connections to the Raven server using fn, for
+// example through a proxy. TLS is used on top of the connection for
+// 'capnprotos' urls or when a TLS config has been set.
+func WithDialer(fn DialFunc) OptionFunc {
+	return func(c *Config) error {
+		if fn == nil {
+			return errors.New("WithDialer called with <nil> dialer")
+		}
+		c.dialer = fn
+		return nil
+	}
+}
+
+//WithHealthAddr serves the health endpoints of HealthHandler on addr.
+//
+//     WithHealthAddr(":8080")
+func WithHealthAddr(addr string) OptionFunc {
+	return func(c *Config) error {
+		c.healthAddr = addr
+		return nil
+	}
+}
+
+//WithReadyThreshold time since the last successful GetJob or Ack after
+// which /readyz reports the worker as not ready.
+func WithReadyThreshold(s string) (OptionFunc, error) {
+	threshold, err := time.ParseDuration(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *Config) error {
+		c.readyThreshold = threshold
+		return nil
+	}, nil
+}
+
+//WithTracing creates spans around every call to the Raven server and
+// every message handled by Run, and exports them to exporter. The trace
+// context is propagated to produced and acknowledged messages using the
+// 'traceparent' and 'tracestate' metadata.
+//
+//     WithTracing(StdoutExporter)
+func WithTracing(exporter SpanExporter) OptionFunc {
+	return func(c *Config) error {
+		if exporter == nil {
+			return fmt.Errorf("WithTracing called with <nil> exporter")
+		}
+		c.exporter = exporter
+		return nil
+	}
+}
+
+//WithLazyConnect returns from New without connecting, the first call
+// connects using the backoff strategy. Use WaitReady to wait for the
+// connection.
+func WithLazyConnect() OptionFunc {
+	return func(c *Config) error {
+		c.lazyConnect = true
+		return nil
+	}
+}
+
 //WithConsumeTimeout time frame to wait for a new message.
 // not setting this equals wait forever.
 func WithConsumeTimeout(s string) (OptionFunc, error) {
@@ -98,6 +171,20 @@ func WithConsumeTimeout(s string) (OptionFunc, error) {
 	}, nil
 }
 
+//WithShutdownTimeout time RunUntilSignal waits for messages that are
+// being handled after a signal has been received.
+func WithShutdownTimeout(s string) (OptionFunc, error) {
+	timeout, err := time.ParseDuration(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *Config) error {
+		c.shutdownTimeout = timeout
+		return nil
+	}, nil
+}
+
 //WithMaxIntake ingest messages until maxIntake is reached.
 func WithMaxIntake(num string) OptionFunc {
 	return func(c *Config) error {
@@ -110,6 +197,91 @@ func WithMaxIntake(num string) OptionFunc {
 	}
 }
 
+//WithConcurrency handles up to n messages at the same time in Run.
+func WithConcurrency(n int) OptionFunc {
+	return func(c *Config) error {
+		if n < 1 {
+			return fmt.Errorf("concurrency should be at least 1, got %d", n)
+		}
+		c.concurrency = n
+		return nil
+	}
+}
+
+//WithDeadLetter requeues messages for which the handler passed to Run
+// returns an error, until it failed maxAttempts times. The message is
+// then passed to dl and acknowledged with the dead letter metadata and
+// the filter flag set.
+func WithDeadLetter(maxAttempts int, dl DeadLetter) OptionFunc {
+	return func(c *Config) error {
+		if maxAttempts < 1 {
+			return fmt.Errorf("max attempts should be at least 1, got %d", maxAttempts)
+		} else if dl == nil {
+			return errors.New("WithDeadLetter called with nil dead letter")
+		}
+		c.maxAttempts = maxAttempts
+		c.deadLetter = dl
+		return nil
+	}
+}
+
+//WithRecover recovers panics in the handler passed to Run. The panic
+// and stack trace are logged, policy decides what happens next.
+//
+//     WithRecover(RecoverNack|RecoverExit)
+func WithRecover(policy RecoverPolicy) OptionFunc {
+	return func(c *Config) error {
+		c.recoverPanics = true
+		c.recoverPolicy = policy
+		return nil
+	}
+}
+
+//WithKeepAlive extends the lease of a message every interval while it
+// is handled by Run, so long running jobs are not handed out to other
+// workers. Zero disables it.
+func WithKeepAlive(s string) (OptionFunc, error) {
+	interval, err := time.ParseDuration(s)
+	if err != nil {
+		return nil, err
+	} else if interval < 0 {
+		return nil, fmt.Errorf("keep alive interval should not be negative, got %s", s)
+	}
+
+	return func(c *Config) error {
+		c.keepAlive = interval
+		return nil
+	}, nil
+}
+
+//WithOutbox sets the number of messages ProduceAsync will queue before
+// blocking and the number of workers producing them in the background.
+func WithOutbox(size, workers int) OptionFunc {
+	return func(c *Config) error {
+		if size < 1 || workers < 1 {
+			return fmt.Errorf("outbox size and workers should be at least 1, got %d and %d", size, workers)
+		}
+		c.outboxSize = size
+		c.outboxWorkers = workers
+		return nil
+	}
+}
+
+//WithSpool stores messages that could not be produced, because the
+// Raven server is unreachable, in dir. They will be produced in order
+// once the connection recovers. The spool will not grow beyond maxSize
+// bytes, zero is no limit.
+func WithSpool(dir string, maxSize int64) OptionFunc {
+	return func(c *Config) error {
+		if dir == "" {
+			return errors.New("WithSpool called with empty directory")
+		}
+		c.spoolDir = dir
+		c.spoolMaxSize = maxSize
+		return nil
+	}
+}
+
 //WithCloser adds an 'io.Closer' to the list.
 func WithCloser(closer io.Closer) OptionFunc {
 	return func(c *Config) error {
@@ -126,7 +298,12 @@ func errorFunc(err error) OptionFunc {
 }
 
 // DefaultEnvironment returns the optionFunc that expects 'RAVEN_URL', 'FLOW_ID' and 'WORKER_ID' as environmental variables
-// 'CONSUME_TIMEOUT' will override the default if set. DefaultLogger is set as the logger.
+// 'RAVEN_TOKEN' authenticates the worker, 'RAVEN_CA_FILE' and 'RAVEN_CERT_FILE' with 'RAVEN_KEY_FILE'
+// configure TLS and client certificates,
+// 'CONSUME_TIMEOUT' and 'SHUTDOWN_TIMEOUT' will override the defaults if set, 'KEEP_ALIVE'
+// will extend the lease of handled messages, 'HEALTH_ADDR' serves the health endpoints,
+// 'TRACE_EXPORTER=stdout' writes spans to stdout, 'MAX_INTAKE' will limit the number of consumed messages.
+// DefaultLogger is set as the logger.
 func DefaultEnvironment() OptionFunc {
 	opts := []OptionFunc{}
 
@@ -155,6 +332,53 @@ func DefaultEnvironment() OptionFunc {
 		opts = append(opts, optionFn)
 	}
 
+	if s := os.Getenv("RAVEN_TOKEN"); s != "" {
+		opts = append(opts, WithAuthToken(s))
+	}
+
+	if s := os.Getenv("RAVEN_CA_FILE"); s == "" {
+	} else if optionFn, err := WithCAFile(s); err != nil {
+		return errorFunc(err)
+	} else {
+		opts = append(opts, optionFn)
+	}
+
+	if s := os.Getenv("RAVEN_CERT_FILE"); s == "" {
+	} else if optionFn, err := WithClientCertificate(s, os.Getenv("RAVEN_KEY_FILE")); err != nil {
+		return errorFunc(err)
+	} else {
+		opts = append(opts, optionFn)
+	}
+
+	if s := os.Getenv("SHUTDOWN_TIMEOUT"); s == "" {
+	} else if optionFn, err := WithShutdownTimeout(s); err != nil {
+		return errorFunc(err)
+	} else {
+		opts = append(opts, optionFn)
+	}
+
+	if s := os.Getenv("HEALTH_ADDR"); s != "" {
+		opts = append(opts, WithHealthAddr(s))
+	}
+
+	if s := os.Getenv("TRACE_EXPORTER"); s == "" {
+	} else if s == "stdout" {
+		opts = append(opts, WithTracing(StdoutExporter))
+	} else {
+		return errorFunc(fmt.Errorf("unsupported trace exporter: %s", s))
+	}
+
+	if s := os.Getenv("KEEP_ALIVE"); s == "" {
+	} else if optionFn, err := WithKeepAlive(s); err != nil {
+		return errorFunc(err)
+	} else {
+		opts = append(opts, optionFn)
+	}
+
+	if s := os.Getenv("MAX_INTAKE"); s != "" {
+		opts = append(opts, WithMaxIntake(s))
+	}
+
 	if optionFn, err := WithLogger(DefaultLogger); err != nil {
 		return errorFunc(err)
 	} else {
@@ -30,3 +30,17 @@ func TestWithLoggerError(t *testing.T) {
 		t.Fatal("expected an error: got none")
 	}
 }
+
+func TestWithConcurrencyError(t *testing.T) {
+	c := &Config{}
+
+	if err := WithConcurrency(0)(c); err == nil {
+		t.Fatal("expected an error: got none")
+	}
+}
+
+func TestWithKeepAliveError(t *testing.T) {
+	if _, err := WithKeepAlive("-1s"); err == nil {
+		t.Fatal("expected an error: got none")
+	}
+}
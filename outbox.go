@@ -0,0 +1,193 @@
+package ravenworker
+
+import (
+	"context"
+	"sync"
+)
+
+// ProduceFuture is returned by ProduceAsync and will be resolved
+// once the message has been produced.
+type ProduceFuture struct {
+	done chan struct{}
+
+	eventID EventID
+	err     error
+}
+
+// Done is closed when the message has been produced or failed.
+func (f *ProduceFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the message has been produced and returns the
+// EventID of the new event.
+func (f *ProduceFuture) Wait(ctx context.Context) (EventID, error) {
+	select {
+	case <-f.done:
+		return f.eventID, f.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+type outboxItem struct {
+	message Message
+	future  *ProduceFuture
+}
+
+// outbox is a bounded queue of messages which are produced by
+// background workers.
+type outbox struct {
+	produce func(Message) (EventID, error)
+	workers int
+
+	items chan outboxItem
+	quit  chan struct{}
+
+	start sync.Once
+	wg    sync.WaitGroup
+
+	m       sync.Mutex
+	closed  bool
+	pending int           // number of messages not produced yet.
+	drained chan struct{} // closed when there are no pending messages.
+}
+
+func newOutbox(size, workers int, produce func(Message) (EventID, error)) *outbox {
+	drained := make(chan struct{})
+	close(drained)
+
+	return &outbox{
+		produce: produce,
+		workers: workers,
+		items:   make(chan outboxItem, size),
+		quit:    make(chan struct{}),
+		drained: drained,
+	}
+}
+
+// put adds message to the outbox. It blocks while the outbox is full.
+func (o *outbox) put(ctx context.Context, message Message) (*ProduceFuture, error) {
+	o.start.Do(func() {
+		for i := 0; i < o.workers; i++ {
+			o.wg.Add(1)
+			go o.work()
+		}
+	})
+
+	o.m.Lock()
+	if o.closed {
+		o.m.Unlock()
+		return nil, ErrClosed
+	}
+
+	if o.pending == 0 {
+		o.drained = make(chan struct{})
+	}
+	o.pending++
+	o.m.Unlock()
+
+	item := outboxItem{
+		message: message,
+		future:  &ProduceFuture{done: make(chan struct{})},
+	}
+
+	select {
+	case o.items <- item:
+		return item.future, nil
+	case <-ctx.Done():
+		o.done()
+		return nil, ctx.Err()
+	case <-o.quit:
+		o.done()
+		return nil, ErrClosed
+	}
+}
+
+// work produces messages from the outbox until it is closed.
+func (o *outbox) work() {
+	defer o.wg.Done()
+
+	for {
+		select {
+		case item := <-o.items:
+			item.future.eventID, item.future.err = o.produce(item.message)
+			close(item.future.done)
+
+			o.done()
+		case <-o.quit:
+			return
+		}
+	}
+}
+
+// done marks a pending message as finished.
+func (o *outbox) done() {
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	o.pending--
+	if o.pending == 0 {
+		close(o.drained)
+	}
+}
+
+// flush waits until all pending messages have been produced.
+func (o *outbox) flush(ctx context.Context) error {
+	o.m.Lock()
+	drained := o.drained
+	o.m.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops accepting new messages, waits until all pending
+// messages have been produced and stops the workers.
+func (o *outbox) close() error {
+	o.m.Lock()
+	if o.closed {
+		o.m.Unlock()
+		return nil
+	}
+	o.closed = true
+	o.m.Unlock()
+
+	err := o.flush(context.Background())
+
+	close(o.quit)
+	o.wg.Wait()
+
+	return err
+}
+
+// ProduceAsync adds the message to the outbox and returns immediately.
+// The message will be produced in the background, using the same
+// backoff strategy as Produce. When the outbox is full, ProduceAsync
+// blocks until there is room or ctx is canceled.
+//
+//     future, err := w.ProduceAsync(ctx, message)
+//     if err != nil {
+//         panic(err)
+//     }
+//
+//     eventID, err := future.Wait(ctx)
+//
+// Use Flush to wait for all messages in the outbox, Close will
+// flush the outbox as well.
+func (c *DefaultWorker) ProduceAsync(ctx context.Context, message Message) (*ProduceFuture, error) {
+	return c.outbox.put(ctx, message)
+}
+
+// Flush waits until all messages in the outbox have been produced.
+func (c *DefaultWorker) Flush(ctx context.Context) error {
+	if c.isClosed() {
+		return ErrClosed
+	}
+
+	return c.outbox.flush(ctx)
+}
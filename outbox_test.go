@@ -0,0 +1,139 @@
+package ravenworker
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dutchsec/raven-worker/workflow"
+	"github.com/gofrs/uuid"
+	context "golang.org/x/net/context"
+)
+
+func TestProduceAsync(t *testing.T) {
+	var counter int32
+
+	srvr, err := testServer(&workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			atomic.AddInt32(&counter, 1)
+
+			eventID, _ := uuid.NewV4()
+			return putNewEvent.Results.SetEventID(eventID.Bytes())
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithOutbox(2, 2),
+		WithBackOff(StopBackOff),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	futures := []*ProduceFuture{}
+
+	for i := 0; i < 10; i++ {
+		future, err := w.ProduceAsync(context.Background(), TestProduceMessage)
+		if err != nil {
+			t.Fatalf("Could not produce message: %s", err.Error())
+		}
+
+		futures = append(futures, future)
+	}
+
+	if err := w.Flush(context.Background()); err != nil {
+		t.Fatalf("Could not flush outbox: %s", err.Error())
+	}
+
+	if n := atomic.LoadInt32(&counter); n != 10 {
+		t.Fatalf("expected 10 produced messages, got %d", n)
+	}
+
+	for i, future := range futures {
+		select {
+		case <-future.Done():
+		default:
+			t.Fatalf("future %d not resolved after flush", i)
+		}
+
+		if eventID, err := future.Wait(context.Background()); err != nil {
+			t.Errorf("message %d failed: %s", i, err)
+		} else if eventID == "" {
+			t.Errorf("message %d has no EventID", i)
+		}
+	}
+
+	w.Close()
+
+	if _, err := w.ProduceAsync(context.Background(), TestProduceMessage); err == nil {
+		t.Fatalf("expected an error after Close.")
+	}
+}
+
+func TestProduceAsyncBackpressure(t *testing.T) {
+	release := make(chan struct{})
+
+	var counter int32
+
+	srvr, err := testServer(&workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			<-release
+
+			atomic.AddInt32(&counter, 1)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithOutbox(1, 1),
+		WithBackOff(StopBackOff),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	// the first message is being produced, the second fills the outbox.
+	for i := 0; i < 2; i++ {
+		if _, err := w.ProduceAsync(context.Background(), TestProduceMessage); err != nil {
+			t.Fatalf("Could not produce message: %s", err.Error())
+		}
+	}
+
+	// wait for the worker to pick up the first message.
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := w.ProduceAsync(ctx, TestProduceMessage); err != context.DeadlineExceeded {
+		t.Fatalf("expected error %v, got: %v", context.DeadlineExceeded, err)
+	}
+
+	close(release)
+
+	// close waits for the outbox to drain.
+	w.Close()
+
+	if n := atomic.LoadInt32(&counter); n != 2 {
+		t.Fatalf("expected 2 produced messages, got %d", n)
+	}
+}
@@ -1,61 +1,140 @@
 package ravenworker
 
 import (
+	"errors"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v3"
 	"github.com/dutchsec/raven-worker/workflow"
+	"github.com/gofrs/uuid"
 	context "golang.org/x/net/context"
 	capnp "zombiezen.com/go/capnproto2"
 )
 
+// EventID identifies an event, it is returned by Produce.
 type EventID string
 
 func (e EventID) String() string {
 	return string(e)
 }
 
-// Produce will store a new message in the queue. If an
-// error occurs it will retry using exponential backoff
-// strategy.
+// Produce will store a new message in the queue and returns
+// the EventID of the new event. If an error occurs it will
+// retry using exponential backoff strategy.
 //
 //     message := NewMessage()
 //         .Content([]byte("test"))
 //
-//    err := w.Produce(message)
+//    eventID, err := w.Produce(ctx, message)
 //    if err != nil {
 //        panic (err)
 //    }
-func (c *DefaultWorker) Produce(message Message) error {
+//
+// The context will cancel the request and any retries.
+//
+// With WithSpool, messages that could not be produced because the
+// server is unreachable are spooled to disk and an empty EventID is
+// returned. While the spool is not empty, new messages will be spooled
+// as well to keep them in order.
+func (c *DefaultWorker) Produce(ctx context.Context, message Message) (EventID, error) {
+	if c.isClosed() {
+		return "", ErrClosed
+	}
+
+	if c.spool == nil {
+		return c.produceWithBackOff(ctx, message)
+	}
+
+	if !c.spool.empty() {
+		return "", c.spool.put(message)
+	}
+
+	eventID, err := c.produceWithBackOff(ctx, message)
+	if !errors.Is(err, ErrConnectionLost) {
+		return eventID, err
+	}
+
+	if serr := c.spool.put(message); serr != nil {
+		c.log.Errorf("Could not spool message: %s", serr)
+		return "", err
+	}
+
+	c.log.Infof("Spooled message, server is unreachable: %s", err)
+	return "", nil
+}
+
+func (c *DefaultWorker) produceWithBackOff(ctx context.Context, message Message) (EventID, error) {
 	var t *time.Timer
 
 	cb := c.newBackOff()
 
-	for {
-		err := c.produce(message)
+	for attempt := 1; ; attempt++ {
+		eventID, err := c.produce(ctx, message)
 		if err == nil {
-			return nil
+			atomic.AddInt64(&c.counters.produced, 1)
+			return eventID, nil
+		} else if permanent(err) {
+			return "", err
 		}
 
 		next := cb.NextBackOff()
 		if next == backoff.Stop {
-			c.log.Errorf("Could not produce message: %s", err)
-			return err
-		} else if t != nil {
+			c.log.Errorw("Could not produce message", "error", err, "attempt", attempt)
+			return "", err
+		}
+
+		c.metrics.produce.retry(next)
+
+		if t != nil {
 			t.Reset(next)
 		} else {
 			t = time.NewTimer(next)
 			defer t.Stop()
 		}
 
-		c.log.Debugf("Got error while producing message: %s. Will retry in %v.", err.Error(), next)
+		c.log.Debugw("Got error while producing message, will retry", "error", err, "attempt", attempt, "retry_in", next)
 
-		<-t.C
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (c *DefaultWorker) produce(ctx context.Context, message Message) (EventID, error) {
+	start := time.Now()
+
+	ctx, span := c.startSpan(ctx, "raven.produce")
+
+	conn, err := c.connection(ctx)
+	if err != nil {
+		c.metrics.produce.done(start, err)
+		span.end(err)
+		return "", err
+	}
+
+	// the new event continues the trace.
+	message.MetaData = span.inject(message.MetaData)
+
+	eventID, err := c.eventID(putNewEvent(ctx, conn, message))
+	c.metrics.produce.done(start, err)
+
+	if err == nil {
+		c.metrics.produce.message(message.Content)
+		span.set("event_id", eventID.String())
 	}
+
+	span.end(err)
+
+	return eventID, err
 }
 
-func (c *DefaultWorker) produce(message Message) error {
-	_, err := c.w.PutNewEvent(context.Background(), func(params workflow.Connection_putNewEvent_Params) error {
+// putNewEvent sends the message to the server without waiting
+// for the result, so calls can be pipelined.
+func putNewEvent(ctx context.Context, conn workflow.Connection, message Message) workflow.Connection_putNewEvent_Results_Promise {
+	return conn.PutNewEvent(ctx, func(params workflow.Connection_putNewEvent_Params) error {
 		_, seg, _ := capnp.NewMessage(capnp.SingleSegment(nil))
 
 		capnpEvent, _ := workflow.NewRootEvent(seg)
@@ -80,7 +159,25 @@ func (c *DefaultWorker) produce(message Message) error {
 		}
 
 		return params.SetEvent(capnpEvent)
-	}).Struct()
+	})
+}
+
+// eventID waits for the result of putNewEvent and returns the
+// EventID of the new event.
+func (c *DefaultWorker) eventID(promise workflow.Connection_putNewEvent_Results_Promise) (EventID, error) {
+	res, err := promise.Struct()
+	if err != nil {
+		return "", c.rpcError(err)
+	}
+
+	// the event has been stored, so we won't retry when the
+	// server did not return a valid event id.
+	eventID, _ := res.EventID()
+
+	eventUUID, err := uuid.FromBytes(eventID)
+	if err != nil {
+		return "", nil
+	}
 
-	return err
+	return EventID(eventUUID.String()), nil
 }
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/cenkalti/backoff/v3"
 	"github.com/dutchsec/raven-worker/workflow"
 	"github.com/gofrs/uuid"
 	"github.com/google/go-cmp/cmp"
+	context "golang.org/x/net/context"
 )
 
 var (
@@ -28,15 +30,11 @@ func StopBackOff() backoff.BackOff {
 }
 
 func TestProduce(t *testing.T) {
-	srvr, err := testServer(&workflowServer{
-		putEvent: func(putEvent workflow.Workflow_putEvent) error {
-			if v, err := putEvent.Params.FlowID(); err != nil {
-				return err
-			} else if _, err := uuid.FromBytes(v); err != nil {
-				return fmt.Errorf("FlowID is not a valid UUID: %s", err)
-			}
+	eventID, _ := uuid.NewV4()
 
-			evt, err := putEvent.Params.Event()
+	srvr, err := testServer(&workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			evt, err := putNewEvent.Params.Event()
 			if err != nil {
 				return err
 			}
@@ -49,7 +47,7 @@ func TestProduce(t *testing.T) {
 			metadata := transformMeta(meta)
 
 			if diff := cmp.Diff(metadata, TestProduceMessage.MetaData); diff != "" {
-				return fmt.Errorf("putEvent() metadata mismatch (-want +got):\n%s", diff)
+				return fmt.Errorf("putNewEvent() metadata mismatch (-want +got):\n%s", diff)
 			}
 
 			content, err := evt.Content()
@@ -58,10 +56,10 @@ func TestProduce(t *testing.T) {
 			}
 
 			if !bytes.Equal(content, TestProduceMessage.Content) {
-				return fmt.Errorf("putEvent() content mismatch want:%s got:%s", string(content), string(TestProduceMessage.Content))
+				return fmt.Errorf("putNewEvent() content mismatch want:%s got:%s", string(content), string(TestProduceMessage.Content))
 			}
 
-			return nil
+			return putNewEvent.Results.SetEventID(eventID.Bytes())
 		},
 	})
 	if err != nil {
@@ -81,7 +79,48 @@ func TestProduce(t *testing.T) {
 		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
 	}
 
-	if err := w.Produce(TestProduceMessage); err != nil {
-		t.Fatalf("Could not consume message: %s", err.Error())
+	id, err := w.Produce(context.Background(), TestProduceMessage)
+	if err != nil {
+		t.Fatalf("Could not produce message: %s", err.Error())
+	}
+
+	if id.String() != eventID.String() {
+		t.Errorf("EventID isn't right. Got %s, want %s", id, eventID.String())
+	}
+}
+
+func TestProduceWithCancel(t *testing.T) {
+	srvr, err := testServer(&workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			return fmt.Errorf("ERROR")
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.NewConstantBackOff(3 * time.Millisecond)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// we are expecting an error
+	if _, err := w.Produce(ctx, TestProduceMessage); err == nil {
+		t.Fatalf("Expected an error.")
+	} else if err != context.DeadlineExceeded {
+		t.Fatalf("expected error %v, got: %v", context.DeadlineExceeded, err)
 	}
 }
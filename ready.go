@@ -0,0 +1,86 @@
+package ravenworker
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+)
+
+// WaitReady blocks until the worker is connected and the server
+// accepted it, or ctx is done. Between attempts it waits using the
+// backoff strategy, starting over when the backoff stops.
+//
+//     w, err := New(DefaultEnvironment(), WithLazyConnect())
+//     if err != nil {
+//         panic(err)
+//     }
+//
+//     if err := w.WaitReady(ctx); err != nil {
+//         panic(err)
+//     }
+func (c *DefaultWorker) WaitReady(ctx context.Context) error {
+	var t *time.Timer
+
+	cb := c.newBackOff()
+
+	for attempt := 1; ; attempt++ {
+		err := c.ready(ctx)
+		if err == nil {
+			return nil
+		} else if permanent(err) {
+			return err
+		}
+
+		next := cb.NextBackOff()
+		if next == backoff.Stop {
+			// keep trying until ctx is done.
+			cb = c.newBackOff()
+			next = cb.NextBackOff()
+		}
+
+		if next == backoff.Stop {
+			c.log.Errorw("Worker not ready", "error", err, "attempt", attempt)
+			return err
+		} else if t != nil {
+			t.Reset(next)
+		} else {
+			t = time.NewTimer(next)
+			defer t.Stop()
+		}
+
+		c.log.Debugw("Worker not ready, will retry", "error", err, "attempt", attempt, "retry_in", next)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// ready connects when needed and waits until the server accepted the
+// worker.
+func (c *DefaultWorker) ready(ctx context.Context) error {
+	if _, err := c.connection(ctx); err != nil {
+		return err
+	}
+
+	c.m.Lock()
+	promise := c.connected
+	c.m.Unlock()
+
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := promise.Struct()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return c.rpcError(err)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
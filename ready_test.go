@@ -0,0 +1,105 @@
+package ravenworker
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/dutchsec/raven-worker/workflow"
+	context "golang.org/x/net/context"
+)
+
+// freeAddr returns an address nobody is listening on.
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %s", err)
+	}
+
+	defer l.Close()
+
+	return l.Addr().String()
+}
+
+func TestLazyConnect(t *testing.T) {
+	addr := freeAddr(t)
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", addr)),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithLazyConnect(),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(backoff.NewConstantBackOff(10*time.Millisecond), 3)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Expected New to succeed without server, got %s", err.Error())
+	}
+
+	defer w.Close()
+
+	// the server starts after the worker.
+	started := make(chan net.Listener, 1)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+
+		srvr, err := testServerAt(addr, &workflowServer{
+			putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+				return nil
+			},
+		})
+		if err != nil {
+			t.Errorf("Could not start test server: %s", err.Error())
+		}
+
+		started <- srvr
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.WaitReady(ctx); err != nil {
+		t.Fatalf("Expected worker to get ready, got %s", err.Error())
+	}
+
+	srvr := <-started
+	if srvr == nil {
+		return
+	}
+
+	defer srvr.Close()
+
+	if _, err := w.Produce(context.Background(), TestProduceMessage); err != nil {
+		t.Fatalf("Could not produce message: %s", err.Error())
+	}
+}
+
+func TestWaitReadyCancel(t *testing.T) {
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", freeAddr(t))),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithLazyConnect(),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(backoff.NewConstantBackOff(10*time.Millisecond), 3)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Expected New to succeed without server, got %s", err.Error())
+	}
+
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := w.WaitReady(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Expected DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package ravenworker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime/debug"
+)
+
+// RecoverPolicy decides what Run does after recovering a panic in the
+// handler, see WithRecover. Without flags the panic is logged and Run
+// continues with the next message.
+type RecoverPolicy int
+
+const (
+	// RecoverNack hands the message back to be requeued. With
+	// WithDeadLetter the panic counts as a failed attempt instead.
+	RecoverNack RecoverPolicy = 1 << iota
+
+	// RecoverExit closes the worker and exits the process with exit
+	// code 1.
+	RecoverExit
+)
+
+// exit is replaced in tests.
+var exit = os.Exit
+
+// PanicError is returned by a handler wrapped with Recover when it
+// panicked.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Recover returns a handler that returns a *PanicError when handler
+// panics. Run does this when WithRecover is set.
+func Recover(handler Handler) Handler {
+	return func(ctx context.Context, message Message) (result Result, err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = &PanicError{
+					Value: v,
+					Stack: debug.Stack(),
+				}
+			}
+		}()
+
+		return handler(ctx, message)
+	}
+}
+
+// recovered logs the panic of the handler for ref and applies the
+// recover policy.
+func (c *DefaultWorker) recovered(ctx context.Context, ref Reference, message Message, perr *PanicError) error {
+	c.log.Errorw("Recovered from panic handling message", "event_id", ref.EventID, "ack_id", ref.AckID, "panic", fmt.Sprint(perr.Value), "stacktrace", string(perr.Stack))
+
+	var err error
+
+	if c.recoverPolicy&RecoverNack == 0 {
+	} else if c.deadLetter != nil {
+		err = c.failed(ctx, ref, message, perr)
+	} else {
+		err = c.Nack(ctx, ref, WithRequeue(), WithReason(perr.Error()))
+	}
+
+	if c.recoverPolicy&RecoverExit != 0 {
+		c.log.Errorw("Exiting after panic handling message", "event_id", ref.EventID, "ack_id", ref.AckID)
+
+		c.Close()
+		exit(1)
+	}
+
+	return err
+}
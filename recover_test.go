@@ -0,0 +1,155 @@
+package ravenworker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/dutchsec/raven-worker/workflow"
+	"github.com/gofrs/uuid"
+	context "golang.org/x/net/context"
+)
+
+func TestRecover(t *testing.T) {
+	handler := Recover(func(ctx context.Context, message Message) (Result, error) {
+		panic("boom")
+	})
+
+	_, err := handler(context.Background(), Message{})
+
+	var perr *PanicError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Expected PanicError, got %v", err)
+	}
+
+	if perr.Value != "boom" {
+		t.Fatalf("Incorrect panic value: %v", perr.Value)
+	}
+
+	if !strings.Contains(string(perr.Stack), "TestRecover") {
+		t.Fatalf("Expected stack trace to contain test function")
+	}
+}
+
+// panicServer hands out a single job and counts nacks and acks.
+func panicServer(nacks, acks *int32) *workflowServer {
+	ackID, _ := uuid.NewV4()
+	eventID, _ := uuid.NewV4()
+
+	var jobs int32
+
+	return &workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			if atomic.AddInt32(&jobs, 1) > 1 {
+				return errors.New("item not found")
+			}
+
+			getJob.Results.SetAckID(ackID.Bytes())
+			getJob.Results.SetEventID(eventID.Bytes())
+			return nil
+		},
+		getEvent: func(getEvent workflow.Connection_getEvent) error {
+			evt, err := getEvent.Results.NewEvent()
+			if err != nil {
+				return err
+			}
+
+			return getEvent.Results.SetEvent(evt)
+		},
+		nackJob: func(nackJob workflow.Connection_nackJob) error {
+			if v, err := nackJob.Params.Reason(); err != nil {
+				return err
+			} else if v != "panic: boom" {
+				return fmt.Errorf("Incorrect reason: %s", v)
+			}
+
+			atomic.AddInt32(nacks, 1)
+
+			nackJob.Results.SetNacked(true)
+			return nil
+		},
+		ackJob: func(ackJob workflow.Connection_ackJob) error {
+			atomic.AddInt32(acks, 1)
+
+			ackJob.Results.SetAcked(true)
+			return nil
+		},
+	}
+}
+
+func TestRunRecover(t *testing.T) {
+	defer func(fn func(int)) { exit = fn }(exit)
+
+	tests := []struct {
+		name   string
+		policy RecoverPolicy
+		nacks  int32
+		exit   int32
+	}{
+		{"continue", 0, 0, 0},
+		{"nack", RecoverNack, 1, 0},
+		{"nack and exit", RecoverNack | RecoverExit, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var nacks, acks, exits int32
+
+			exit = func(code int) {
+				if code != 1 {
+					t.Errorf("Expected exit code 1, got %d", code)
+				}
+
+				atomic.AddInt32(&exits, 1)
+			}
+
+			srvr, err := testServer(panicServer(&nacks, &acks))
+			if err != nil {
+				t.Fatalf("Could not start test server: %s", err.Error())
+			}
+
+			defer srvr.Close()
+
+			w, err := New(
+				MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+				MustWithFlowID(flowID.String()),
+				MustWithWorkerID(workerID.String()),
+				MustWithLogger(DefaultLogger),
+				MustWithConsumeTimeout("100ms"),
+				WithRecover(tt.policy),
+				WithBackOff(func() backoff.BackOff {
+					return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)
+				}),
+			)
+			if err != nil {
+				t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+			}
+
+			defer w.Close()
+
+			err = w.Run(context.Background(), func(ctx context.Context, message Message) (Result, error) {
+				panic("boom")
+			})
+			if tt.exit > 0 && errors.Is(err, ErrClosed) {
+				// exit has been stubbed, the worker has been closed.
+			} else if err != nil {
+				t.Fatalf("Run returned error: %s", err.Error())
+			}
+
+			if n := atomic.LoadInt32(&nacks); n != tt.nacks {
+				t.Fatalf("Expected %d nacks, got %d", tt.nacks, n)
+			}
+
+			if n := atomic.LoadInt32(&acks); n != 0 {
+				t.Fatalf("Expected no acks, got %d", n)
+			}
+
+			if n := atomic.LoadInt32(&exits); n != tt.exit {
+				t.Fatalf("Expected %d exits, got %d", tt.exit, n)
+			}
+		})
+	}
+}
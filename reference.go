@@ -3,4 +3,8 @@ package ravenworker
 type Reference struct {
 	AckID   string `json:"ack_id"`
 	EventID string `json:"event_id"`
+
+	// Deliveries is the number of times the server handed out the
+	// message, including this time. Zero if the server does not know.
+	Deliveries int `json:"deliveries,omitempty"`
 }
@@ -0,0 +1,216 @@
+package ravenworker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Handler processes a message for Run. The returned Result decides
+// how the message will be acknowledged. When an error is returned,
+// Run will stop and return the error, unless WithDeadLetter is set.
+// LoggerFromContext(ctx) returns a logger bound to the message.
+type Handler func(ctx context.Context, message Message) (Result, error)
+
+// Result of a Handler.
+type Result struct {
+	// Message will replace the content and metadata of the event,
+	// if set.
+	Message *Message
+
+	// Filter will keep the flow from processing further.
+	Filter bool
+}
+
+// ackOptions returns the options to acknowledge the result with.
+func (r Result) ackOptions() []AckOptionFunc {
+	options := []AckOptionFunc{}
+
+	if r.Message != nil {
+		options = append(options, WithMessage(*r.Message))
+	}
+
+	if r.Filter {
+		options = append(options, WithFilter())
+	}
+
+	return options
+}
+
+// Run will consume, get and acknowledge messages, calling handler for
+// every message.
+//
+//     err := w.Run(ctx, func(ctx context.Context, message Message) (Result, error) {
+//         message.Content = StringContent("test")
+//         return Result{Message: &message}, nil
+//     })
+//     if err != nil {
+//         panic(err)
+//     }
+//
+// Run returns nil when no message has been received within
+// 'consumeTimeout', so the worker can stop, or when ctx is canceled.
+// ErrMaxIntakeReached is returned once 'maxIntake' messages have been
+// handled.
+//
+// With WithConcurrency up to n messages are handled at the same time.
+// Run waits for all outstanding handlers before it returns, Close
+// should be called after Run has returned.
+//
+// Use this function for the 'transform' and 'load' worker types.
+func (c *DefaultWorker) Run(ctx context.Context, handler Handler) error {
+	return c.run(ctx, ctx, handler)
+}
+
+// run consumes messages until ctx is canceled, the handlers and
+// acknowledgements use handleCtx.
+func (c *DefaultWorker) run(ctx, handleCtx context.Context, handler Handler) error {
+	if c.recoverPanics {
+		handler = Recover(handler)
+	}
+
+	// consuming stops when ctx is canceled or on the first error,
+	// outstanding handlers will finish using handleCtx.
+	consumeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg     sync.WaitGroup
+		once   sync.Once
+		runErr error
+	)
+
+	fail := func(err error) {
+		once.Do(func() {
+			runErr = err
+			cancel()
+		})
+	}
+
+	// a slot is taken for every message that is being handled.
+	slots := make(chan struct{}, c.concurrency)
+
+	for consumeCtx.Err() == nil {
+		select {
+		case slots <- struct{}{}:
+		case <-consumeCtx.Done():
+			continue
+		}
+
+		ref, ok, err := c.next(consumeCtx)
+		if err != nil {
+			fail(err)
+		}
+
+		if !ok {
+			<-slots
+			break
+		}
+
+		wg.Add(1)
+		go func(ref Reference) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			if err := c.handle(handleCtx, ref, handler); err != nil {
+				fail(err)
+			}
+		}(ref)
+	}
+
+	wg.Wait()
+	return runErr
+}
+
+// next consumes the next reference. It returns false when Run should
+// stop consuming, because ctx has been canceled, no message has been
+// received within 'consumeTimeout' or an error occurred, including
+// ErrMaxIntakeReached.
+func (c *DefaultWorker) next(ctx context.Context) (Reference, bool, error) {
+	ref, err := c.Consume(ctx)
+	if ctx.Err() != nil {
+		return Reference{}, false, nil
+	} else if errors.Is(err, ErrTimeout) {
+		c.log.Infof("No messages received, stopping.")
+		return Reference{}, false, nil
+	} else if err != nil {
+		return Reference{}, false, err
+	}
+
+	return ref, true, nil
+}
+
+// handle gets the message for ref, calls handler and acknowledges
+// the result. With WithKeepAlive the lease of the message is extended
+// while it is handled.
+func (c *DefaultWorker) handle(ctx context.Context, ref Reference, handler Handler) error {
+	atomic.AddInt64(&c.metrics.inFlight, 1)
+	defer atomic.AddInt64(&c.metrics.inFlight, -1)
+
+	// every line about this message can be grouped by event_id.
+	log := &elapsedLogger{
+		FieldLogger: c.log.With(Field{"event_id", ref.EventID}, Field{"ack_id", ref.AckID}),
+		start:       time.Now(),
+	}
+
+	ctx = ContextWithLogger(ctx, log)
+
+	hctx := ctx
+
+	if c.keepAlive > 0 {
+		var stop context.CancelFunc
+		hctx, stop = c.KeepAlive(ctx, ref, c.keepAlive)
+		defer stop()
+	}
+
+	message, err := c.Get(hctx, ref)
+	if hctx.Err() != nil && ctx.Err() == nil {
+		log.Errorw("Lost lease of message, skipping")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// continue the trace of the message.
+	if sc, ok := TraceContext(message); ok {
+		ctx = ContextWithSpanContext(ctx, sc)
+		hctx = ContextWithSpanContext(hctx, sc)
+	}
+
+	ctx, span := c.startSpan(ctx, "raven.handle")
+	span.set("event_id", ref.EventID)
+
+	hctx = span.with(hctx)
+
+	var perr *PanicError
+
+	result, err := handler(hctx, message)
+	span.end(err)
+
+	if hctx.Err() != nil && ctx.Err() == nil {
+		// the message may be handled by another worker already.
+		log.Errorw("Lost lease of message, not acknowledging")
+		return nil
+	} else if errors.As(err, &perr) && c.recoverPanics {
+		return c.recovered(ctx, ref, message, perr)
+	} else if err != nil {
+		log.Errorw("Could not handle message", "error", err)
+
+		if c.deadLetter == nil {
+			return err
+		}
+
+		return c.failed(ctx, ref, message, err)
+	}
+
+	c.attempts.forget(ref.EventID)
+
+	if err := c.Ack(ctx, ref, result.ackOptions()...); err != nil {
+		return err
+	}
+
+	log.Debugw("Handled message", "filter", result.Filter)
+	return nil
+}
@@ -0,0 +1,374 @@
+package ravenworker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/dutchsec/raven-worker/workflow"
+	"github.com/gofrs/uuid"
+	context "golang.org/x/net/context"
+)
+
+func TestRun(t *testing.T) {
+	ackID, _ := uuid.NewV4()
+	eventID, _ := uuid.NewV4()
+
+	var jobs, acks int32
+
+	srvr, err := testServer(&workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			// hand out a single job.
+			if atomic.AddInt32(&jobs, 1) > 1 {
+				return errors.New("item not found")
+			}
+
+			getJob.Results.SetAckID(ackID.Bytes())
+			getJob.Results.SetEventID(eventID.Bytes())
+			return nil
+		},
+		getEvent: func(getEvent workflow.Connection_getEvent) error {
+			evt, err := getEvent.Results.NewEvent()
+			if err != nil {
+				return err
+			}
+
+			evt.SetContent(StringContent("test"))
+			return getEvent.Results.SetEvent(evt)
+		},
+		ackJob: func(ackJob workflow.Connection_ackJob) error {
+			event, err := ackJob.Params.Event()
+			if err != nil {
+				return err
+			}
+
+			if v, err := event.Content(); err != nil {
+				return err
+			} else if !bytes.Equal(StringContent("test handled"), v) {
+				return fmt.Errorf("Incorrect message content: %s", string(v))
+			}
+
+			atomic.AddInt32(&acks, 1)
+
+			ackJob.Results.SetAcked(true)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		MustWithConsumeTimeout("100ms"),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	err = w.Run(context.Background(), func(ctx context.Context, message Message) (Result, error) {
+		message.Content = append(message.Content, StringContent(" handled")...)
+		return Result{Message: &message}, nil
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %s", err.Error())
+	}
+
+	if n := atomic.LoadInt32(&acks); n != 1 {
+		t.Fatalf("expected 1 acknowledged message, got %d", n)
+	}
+}
+
+func TestRunHandlerError(t *testing.T) {
+	ackID, _ := uuid.NewV4()
+	eventID, _ := uuid.NewV4()
+
+	srvr, err := testServer(&workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			getJob.Results.SetAckID(ackID.Bytes())
+			getJob.Results.SetEventID(eventID.Bytes())
+			return nil
+		},
+		getEvent: func(getEvent workflow.Connection_getEvent) error {
+			evt, err := getEvent.Results.NewEvent()
+			if err != nil {
+				return err
+			}
+
+			return getEvent.Results.SetEvent(evt)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		MustWithConsumeTimeout("100ms"),
+		WithBackOff(StopBackOff),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	e := errors.New("handler error")
+
+	err = w.Run(context.Background(), func(ctx context.Context, message Message) (Result, error) {
+		return Result{}, e
+	})
+	if err != e {
+		t.Fatalf("expected error %v, got: %v", e, err)
+	}
+}
+
+func TestRunConcurrency(t *testing.T) {
+	var jobs, acks int32
+
+	srvr, err := testServer(&workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			if atomic.AddInt32(&jobs, 1) > 8 {
+				return errors.New("item not found")
+			}
+
+			ackID, _ := uuid.NewV4()
+			eventID, _ := uuid.NewV4()
+
+			getJob.Results.SetAckID(ackID.Bytes())
+			getJob.Results.SetEventID(eventID.Bytes())
+			return nil
+		},
+		getEvent: func(getEvent workflow.Connection_getEvent) error {
+			evt, err := getEvent.Results.NewEvent()
+			if err != nil {
+				return err
+			}
+
+			return getEvent.Results.SetEvent(evt)
+		},
+		ackJob: func(ackJob workflow.Connection_ackJob) error {
+			atomic.AddInt32(&acks, 1)
+
+			ackJob.Results.SetAcked(true)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		MustWithConsumeTimeout("100ms"),
+		WithConcurrency(4),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	var active, max int32
+
+	err = w.Run(context.Background(), func(ctx context.Context, message Message) (Result, error) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		return Result{}, nil
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %s", err.Error())
+	}
+
+	if n := atomic.LoadInt32(&acks); n != 8 {
+		t.Fatalf("expected 8 acknowledged messages, got %d", n)
+	}
+
+	if m := atomic.LoadInt32(&max); m < 2 || m > 4 {
+		t.Fatalf("expected between 2 and 4 concurrent handlers, got %d", m)
+	}
+}
+
+func TestRunLogger(t *testing.T) {
+	ackID, _ := uuid.NewV4()
+	eventID, _ := uuid.NewV4()
+
+	var jobs int32
+
+	srvr, err := testServer(&workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			// hand out a single job.
+			if atomic.AddInt32(&jobs, 1) > 1 {
+				return errors.New("item not found")
+			}
+
+			getJob.Results.SetAckID(ackID.Bytes())
+			getJob.Results.SetEventID(eventID.Bytes())
+			return nil
+		},
+		getEvent: func(getEvent workflow.Connection_getEvent) error {
+			evt, err := getEvent.Results.NewEvent()
+			if err != nil {
+				return err
+			}
+
+			return getEvent.Results.SetEvent(evt)
+		},
+		ackJob: func(ackJob workflow.Connection_ackJob) error {
+			ackJob.Results.SetAcked(true)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	r := &printfRecorder{}
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(r),
+		MustWithConsumeTimeout("100ms"),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	err = w.Run(context.Background(), func(ctx context.Context, message Message) (Result, error) {
+		LoggerFromContext(ctx).Infow("Handling message")
+		return Result{}, nil
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %s", err.Error())
+	}
+
+	line, ok := r.find("Handling message")
+	if !ok {
+		t.Fatalf("Expected handler to log using the message logger, got %v", r.lines)
+	}
+
+	for _, want := range []string{
+		"flow_id=" + flowID.String(),
+		"worker_id=" + workerID.String(),
+		"event_id=" + eventID.String(),
+		"ack_id=" + ackID.String(),
+		"elapsed=",
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("Expected %s in line: %s", want, line)
+		}
+	}
+}
+
+func TestRunWaitForever(t *testing.T) {
+	defer fastPoll()()
+
+	ackID, _ := uuid.NewV4()
+	eventID, _ := uuid.NewV4()
+
+	var jobs, acks int32
+
+	// stop running after the message has been handled.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srvr, err := testServer(&workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			// no messages for longer than the backoff retries.
+			if n := atomic.AddInt32(&jobs, 1); n <= 10 {
+				return errors.New("item not found")
+			} else if n > 11 {
+				cancel()
+				return errors.New("item not found")
+			}
+
+			getJob.Results.SetAckID(ackID.Bytes())
+			getJob.Results.SetEventID(eventID.Bytes())
+			return nil
+		},
+		getEvent: func(getEvent workflow.Connection_getEvent) error {
+			evt, err := getEvent.Results.NewEvent()
+			if err != nil {
+				return err
+			}
+
+			return getEvent.Results.SetEvent(evt)
+		},
+		ackJob: func(ackJob workflow.Connection_ackJob) error {
+			atomic.AddInt32(&acks, 1)
+
+			ackJob.Results.SetAcked(true)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		MustWithConsumeTimeout("0s"),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Millisecond), 2)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	err = w.Run(ctx, func(ctx context.Context, message Message) (Result, error) {
+		return Result{}, nil
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %s", err.Error())
+	}
+
+	if n := atomic.LoadInt32(&acks); n != 1 {
+		t.Fatalf("Expected Run to keep polling until a message is handled, got %d acks", n)
+	}
+}
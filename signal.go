@@ -0,0 +1,92 @@
+package ravenworker
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// shutdownSignals are the signals the orchestrator uses to stop a
+// worker.
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// SignalContext returns a context that is canceled when SIGTERM or
+// SIGINT is received, or when cancel is called. Use it to stop an
+// extract worker loop.
+//
+//     ctx, cancel := SignalContext(context.Background())
+//     defer cancel()
+//
+//     for ctx.Err() == nil {
+//         w.Produce(ctx, message)
+//     }
+func SignalContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, shutdownSignals...)
+
+	go func() {
+		defer signal.Stop(ch)
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+		}
+
+		cancel()
+	}()
+
+	return ctx, cancel
+}
+
+// RunUntilSignal calls Run until SIGTERM or SIGINT is received or ctx
+// is canceled. After a signal no new messages are consumed, messages
+// that are being handled get up to 'shutdownTimeout' to be
+// acknowledged before their context is canceled.
+//
+//     err := w.RunUntilSignal(ctx, handler)
+//
+// The worker, including the registered closers, is closed before
+// RunUntilSignal returns.
+func (c *DefaultWorker) RunUntilSignal(ctx context.Context, handler Handler) error {
+	defer c.Close()
+
+	sigCtx, stop := SignalContext(ctx)
+	defer stop()
+
+	handleCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-done:
+			return
+		case <-sigCtx.Done():
+		}
+
+		// ctx has been canceled, so have the handlers.
+		if ctx.Err() != nil {
+			return
+		}
+
+		c.log.Infof("Received signal, waiting up to %v for messages being handled.", c.shutdownTimeout)
+
+		t := time.NewTimer(c.shutdownTimeout)
+		defer t.Stop()
+
+		select {
+		case <-done:
+		case <-t.C:
+			c.log.Errorf("Shutdown timeout expired, canceling messages being handled.")
+			cancel()
+		}
+	}()
+
+	return c.run(sigCtx, handleCtx, handler)
+}
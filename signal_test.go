@@ -0,0 +1,163 @@
+package ravenworker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/dutchsec/raven-worker/workflow"
+	"github.com/gofrs/uuid"
+	context "golang.org/x/net/context"
+)
+
+type testCloser struct {
+	closed int32
+}
+
+func (c *testCloser) Close() error {
+	atomic.AddInt32(&c.closed, 1)
+	return nil
+}
+
+// signalServer hands out jobs until the test ends and counts acks.
+func signalServer(acks *int32) *workflowServer {
+	return &workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			ackID, _ := uuid.NewV4()
+			eventID, _ := uuid.NewV4()
+
+			getJob.Results.SetAckID(ackID.Bytes())
+			getJob.Results.SetEventID(eventID.Bytes())
+			return nil
+		},
+		getEvent: func(getEvent workflow.Connection_getEvent) error {
+			evt, err := getEvent.Results.NewEvent()
+			if err != nil {
+				return err
+			}
+
+			return getEvent.Results.SetEvent(evt)
+		},
+		ackJob: func(ackJob workflow.Connection_ackJob) error {
+			atomic.AddInt32(acks, 1)
+
+			ackJob.Results.SetAcked(true)
+			return nil
+		},
+	}
+}
+
+func sigterm() {
+	p, _ := os.FindProcess(os.Getpid())
+	p.Signal(syscall.SIGTERM)
+}
+
+func TestRunUntilSignal(t *testing.T) {
+	var acks, handled int32
+
+	srvr, err := testServer(signalServer(&acks))
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	closer := &testCloser{}
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithCloser(closer),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	err = w.RunUntilSignal(context.Background(), func(ctx context.Context, message Message) (Result, error) {
+		if atomic.AddInt32(&handled, 1) == 1 {
+			sigterm()
+
+			// the message being handled should still be acknowledged.
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		if ctx.Err() != nil {
+			return Result{}, ctx.Err()
+		}
+
+		return Result{}, nil
+	})
+	if err != nil {
+		t.Fatalf("RunUntilSignal returned error: %s", err.Error())
+	}
+
+	if n := atomic.LoadInt32(&handled); n != 1 {
+		t.Fatalf("Expected 1 message to be handled, got %d", n)
+	}
+
+	if n := atomic.LoadInt32(&acks); n != 1 {
+		t.Fatalf("Expected 1 ack, got %d", n)
+	}
+
+	if n := atomic.LoadInt32(&closer.closed); n != 1 {
+		t.Fatalf("Expected closer to be closed once, got %d", n)
+	}
+}
+
+func TestRunUntilSignalTimeout(t *testing.T) {
+	var acks int32
+
+	srvr, err := testServer(signalServer(&acks))
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	timeout, err := WithShutdownTimeout("50ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		timeout,
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	err = w.RunUntilSignal(context.Background(), func(ctx context.Context, message Message) (Result, error) {
+		sigterm()
+
+		select {
+		case <-ctx.Done():
+			return Result{}, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return Result{}, nil
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected handler to be canceled, got %v", err)
+	}
+
+	if n := atomic.LoadInt32(&acks); n != 0 {
+		t.Fatalf("Expected no acks, got %d", n)
+	}
+}
@@ -0,0 +1,75 @@
+//go:build go1.21
+// +build go1.21
+
+package ravenworker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+)
+
+// LevelFatal is the slog level of Fatalf.
+const LevelFatal = slog.LevelError + 4
+
+// SlogLogger returns a FieldLogger writing to the log/slog handler h.
+// Fatalf logs at LevelFatal and exits.
+//
+//     log := SlogLogger(slog.NewJSONHandler(os.Stdout, nil))
+func SlogLogger(h slog.Handler) FieldLogger {
+	return &slogLogger{l: slog.New(h)}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (l *slogLogger) Debugf(msg string, args ...interface{}) {
+	l.l.Debug(fmt.Sprintf(msg, args...))
+}
+
+func (l *slogLogger) Infof(msg string, args ...interface{}) {
+	l.l.Info(fmt.Sprintf(msg, args...))
+}
+
+func (l *slogLogger) Errorf(msg string, args ...interface{}) {
+	l.l.Error(fmt.Sprintf(msg, args...))
+}
+
+func (l *slogLogger) Fatalf(msg string, args ...interface{}) {
+	l.l.Log(context.Background(), LevelFatal, fmt.Sprintf(msg, args...), slog.String("stacktrace", string(debug.Stack())))
+	exit(1)
+}
+
+func (l *slogLogger) With(fields ...Field) FieldLogger {
+	return &slogLogger{l: l.l.With(slogArgs(fields)...)}
+}
+
+func (l *slogLogger) Debugw(msg string, keyvals ...interface{}) {
+	l.l.Debug(msg, slogArgs(Fields(keyvals...))...)
+}
+
+func (l *slogLogger) Infow(msg string, keyvals ...interface{}) {
+	l.l.Info(msg, slogArgs(Fields(keyvals...))...)
+}
+
+func (l *slogLogger) Errorw(msg string, keyvals ...interface{}) {
+	l.l.Error(msg, slogArgs(Fields(keyvals...))...)
+}
+
+// slogArgs converts fields to slog attributes, errors are logged as
+// strings.
+func slogArgs(fields []Field) []interface{} {
+	args := make([]interface{}, len(fields))
+
+	for i, f := range fields {
+		if err, ok := f.Value.(error); ok {
+			args[i] = slog.String(f.Key, err.Error())
+		} else {
+			args[i] = slog.Any(f.Key, f.Value)
+		}
+	}
+
+	return args
+}
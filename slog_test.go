@@ -0,0 +1,27 @@
+//go:build go1.21
+// +build go1.21
+
+package ravenworker
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	l := SlogLogger(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	l.With(Field{"flow_id", "f"}).Debugw("A", "event_id", "e", "error", errors.New("B"))
+	l.Infof("C%s", "D")
+
+	for _, want := range []string{`"level":"DEBUG"`, `"msg":"A"`, `"flow_id":"f"`, `"event_id":"e"`, `"error":"B"`, `"msg":"CD"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("did not find %s in output: %s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package ravenworker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSpoolFull is returned when a message could not be spooled,
+// because the spool reached its maximum size.
+var ErrSpoolFull = errors.New("spool full")
+
+// spoolSegmentSize is the size at which a new segment is started.
+const spoolSegmentSize = 4 << 20
+
+// spoolOffsetFile persists the read offset of the oldest segment.
+const spoolOffsetFile = "offset"
+
+// errSpoolCorrupt is returned by next for a line that can't be decoded.
+var errSpoolCorrupt = errors.New("corrupt spooled message")
+
+// SpoolStats describes the messages waiting in the spool.
+type SpoolStats struct {
+	Messages int   // number of messages waiting to be produced.
+	Bytes    int64 // disk space used by the segments.
+	Segments int   // number of segment files.
+}
+
+// spool persists messages in append-only segment files, one JSON
+// encoded message per line, until they can be produced.
+//
+// The read offset of the oldest segment is persisted after every
+// replayed message, so a reopened spool continues where it stopped.
+// Messages are replayed at least once: a crash right after producing
+// a message will replay that message again.
+type spool struct {
+	dir     string
+	maxSize int64
+
+	m sync.Mutex
+
+	segments []uint64 // sequence numbers of the segments, oldest first.
+	w        *os.File // segment being written, nil if sealed.
+	wsize    int64    // size of the segment being written.
+
+	offset   int64    // read offset in the oldest segment.
+	of       *os.File // the offset file.
+	size     int64
+	messages int
+}
+
+func openSpool(dir string, maxSize int64) (*spool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	s := &spool{
+		dir:     dir,
+		maxSize: maxSize,
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fi := range files {
+		if !strings.HasSuffix(fi.Name(), ".spool") {
+			continue
+		}
+
+		seq, err := strconv.ParseUint(strings.TrimSuffix(fi.Name(), ".spool"), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		s.segments = append(s.segments, seq)
+		s.size += int64(len(data))
+		s.messages += bytes.Count(data, []byte("\n"))
+	}
+
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i] < s.segments[j] })
+
+	if err := s.loadOffset(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// loadOffset continues at the persisted offset, if it belongs to the
+// oldest segment. The messages before the offset have been produced.
+func (s *spool) loadOffset() error {
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, spoolOffsetFile))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var seq uint64
+	var offset int64
+
+	// the segment may have been removed before the offset was saved.
+	if _, err := fmt.Sscanf(string(data), "%d %d", &seq, &offset); err != nil {
+		return nil
+	} else if len(s.segments) == 0 || s.segments[0] != seq {
+		return nil
+	}
+
+	segment, err := ioutil.ReadFile(s.path(seq))
+	if err != nil {
+		return err
+	}
+
+	if offset < 0 || offset > int64(len(segment)) {
+		return nil
+	}
+
+	s.offset = offset
+	s.messages -= bytes.Count(segment[:offset], []byte("\n"))
+	return nil
+}
+
+// saveOffset persists the read offset of the oldest segment. The
+// caller must hold s.m.
+func (s *spool) saveOffset() error {
+	if s.of == nil {
+		f, err := os.OpenFile(filepath.Join(s.dir, spoolOffsetFile), os.O_CREATE|os.O_RDWR, 0600)
+		if err != nil {
+			return err
+		}
+
+		s.of = f
+	}
+
+	var seq uint64
+	if len(s.segments) > 0 {
+		seq = s.segments[0]
+	}
+
+	// fixed width, so the file never has to be truncated.
+	if _, err := s.of.WriteAt([]byte(fmt.Sprintf("%020d %020d\n", seq, s.offset)), 0); err != nil {
+		return err
+	}
+
+	return s.of.Sync()
+}
+
+func (s *spool) path(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d.spool", seq))
+}
+
+// put appends message to the spool.
+func (s *spool) put(message Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	data = append(data, '\n')
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.maxSize > 0 && s.size+int64(len(data)) > s.maxSize {
+		return ErrSpoolFull
+	}
+
+	if s.w != nil && s.wsize+int64(len(data)) > spoolSegmentSize {
+		s.seal()
+	}
+
+	if s.w == nil {
+		var seq uint64
+		if len(s.segments) > 0 {
+			seq = s.segments[len(s.segments)-1] + 1
+		}
+
+		f, err := os.OpenFile(s.path(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+
+		s.segments = append(s.segments, seq)
+		s.w = f
+		s.wsize = 0
+	}
+
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+
+	if err := s.w.Sync(); err != nil {
+		return err
+	}
+
+	s.wsize += int64(len(data))
+	s.size += int64(len(data))
+	s.messages++
+	return nil
+}
+
+// seal closes the segment being written. The caller must hold s.m.
+func (s *spool) seal() {
+	if s.w == nil {
+		return
+	}
+
+	s.w.Close()
+	s.w = nil
+}
+
+// empty returns true if there are no messages waiting.
+func (s *spool) empty() bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.messages == 0
+}
+
+// stats returns the current spool statistics.
+func (s *spool) stats() SpoolStats {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return SpoolStats{
+		Messages: s.messages,
+		Bytes:    s.size,
+		Segments: len(s.segments),
+	}
+}
+
+// replay produces the spooled messages in order. It stops at the
+// first error, the message will be replayed on the next call. Lines
+// that can't be decoded are skipped and passed to drop.
+func (s *spool) replay(produce func(Message) error, drop func(err error)) error {
+	for {
+		message, n, ok, err := s.next()
+		if errors.Is(err, errSpoolCorrupt) {
+			// it will never decode, don't let it block the spool.
+			drop(err)
+		} else if err != nil || !ok {
+			return err
+		} else if err := produce(message); err != nil {
+			return err
+		}
+
+		if err := s.advance(n); err != nil {
+			return err
+		}
+	}
+}
+
+// next reads the next message from the oldest segment. It returns
+// false when there are no messages left.
+func (s *spool) next() (Message, int64, bool, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	for len(s.segments) > 0 {
+		seq := s.segments[0]
+
+		// don't read the segment that is being written to.
+		if len(s.segments) == 1 {
+			s.seal()
+		}
+
+		f, err := os.Open(s.path(seq))
+		if err != nil {
+			return Message{}, 0, false, err
+		}
+
+		line, err := readLine(f, s.offset)
+		f.Close()
+
+		if err == io.EOF {
+			// segment is done.
+			fi, _ := os.Stat(s.path(seq))
+			if fi != nil {
+				s.size -= fi.Size()
+			}
+
+			if err := os.Remove(s.path(seq)); err != nil {
+				return Message{}, 0, false, err
+			}
+
+			s.segments = s.segments[1:]
+			s.offset = 0
+
+			if err := s.saveOffset(); err != nil {
+				return Message{}, 0, false, err
+			}
+
+			continue
+		} else if err != nil {
+			return Message{}, 0, false, err
+		}
+
+		var message Message
+		if err := json.Unmarshal(line, &message); err != nil {
+			return Message{}, int64(len(line)), false, fmt.Errorf("%w at offset %d of %s: %s", errSpoolCorrupt, s.offset, s.path(seq), err)
+		}
+
+		return message, int64(len(line)), true, nil
+	}
+
+	return Message{}, 0, false, nil
+}
+
+// advance marks the message read by next as produced, and persists
+// the new offset.
+func (s *spool) advance(n int64) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.offset += n
+	s.messages--
+
+	return s.saveOffset()
+}
+
+// close closes the segment being written and the offset file.
+func (s *spool) close() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.seal()
+
+	if s.of != nil {
+		s.of.Close()
+		s.of = nil
+	}
+
+	return nil
+}
+
+// readLine reads the line at offset, including the newline. A line
+// without newline, from an interrupted write, is treated as the end
+// of the segment.
+func readLine(f *os.File, offset int64) ([]byte, error) {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err == io.EOF {
+		return nil, io.EOF
+	} else if err != nil {
+		return nil, err
+	}
+
+	return line, nil
+}
+
+// spoolReplayInterval is the time between attempts to replay the spool.
+var spoolReplayInterval = 1 * time.Second
+
+// startSpool starts replaying the spool in the background, until Close
+// is called.
+func (c *DefaultWorker) startSpool(s *spool) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c.spool = s
+	c.stopReplay = cancel
+	c.replayDone = make(chan struct{})
+
+	go func() {
+		defer close(c.replayDone)
+
+		tick := time.NewTicker(spoolReplayInterval)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-tick.C:
+			case <-ctx.Done():
+				return
+			}
+
+			if c.spool.empty() {
+				continue
+			}
+
+			err := c.spool.replay(func(message Message) error {
+				_, err := c.produce(ctx, message)
+				if ctx.Err() != nil {
+					// closing, keep the message for the next start.
+					return ctx.Err()
+				} else if rejected(err) {
+					// don't let a rejected message block the spool.
+					c.log.Errorf("Could not produce spooled message, dropping it: %s", err)
+					return nil
+				}
+				return err
+			}, func(err error) {
+				c.log.Errorf("Could not decode spooled message, dropping it: %s", err)
+			})
+			if err != nil {
+				c.log.Debugf("Could not replay spool: %s. Will retry in %v.", err, spoolReplayInterval)
+			}
+		}
+	}()
+}
+
+// SpoolStats returns the number of messages waiting in the spool.
+func (c *DefaultWorker) SpoolStats() SpoolStats {
+	if c.spool == nil {
+		return SpoolStats{}
+	}
+
+	return c.spool.stats()
+}
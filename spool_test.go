@@ -0,0 +1,531 @@
+package ravenworker
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/dutchsec/raven-worker/workflow"
+	context "golang.org/x/net/context"
+	capnp "zombiezen.com/go/capnproto2"
+	"zombiezen.com/go/capnproto2/rpc"
+	rpccapnp "zombiezen.com/go/capnproto2/std/capnp/rpc"
+)
+
+func TestSpoolReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	s, err := openSpool(dir, 0)
+	if err != nil {
+		t.Fatalf("Could not open spool: %s", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := s.put(Message{Content: StringContent(fmt.Sprintf("%d", i))}); err != nil {
+			t.Fatalf("Could not spool message: %s", err)
+		}
+	}
+
+	s.close()
+
+	// reopen to make sure the messages are persisted.
+	s, err = openSpool(dir, 0)
+	if err != nil {
+		t.Fatalf("Could not open spool: %s", err)
+	}
+
+	if stats := s.stats(); stats.Messages != 3 || stats.Segments != 1 {
+		t.Fatalf("unexpected spool stats: %+v", stats)
+	}
+
+	replayed := []string{}
+
+	// fail the second message, it should be replayed again.
+	failed := false
+
+	replay := func(message Message) error {
+		if string(message.Content) == "1" && !failed {
+			failed = true
+			return errors.New("ERROR")
+		}
+
+		replayed = append(replayed, string(message.Content))
+		return nil
+	}
+
+	if err := s.replay(replay, drop(t)); err == nil {
+		t.Fatalf("Expected an error.")
+	}
+
+	if err := s.replay(replay, drop(t)); err != nil {
+		t.Fatalf("Could not replay spool: %s", err)
+	}
+
+	if fmt.Sprint(replayed) != "[0 1 2]" {
+		t.Fatalf("messages replayed out of order: %v", replayed)
+	}
+
+	if stats := s.stats(); stats != (SpoolStats{}) {
+		t.Fatalf("expected an empty spool, got: %+v", stats)
+	}
+}
+
+// drop fails the test when a spooled message is dropped.
+func drop(t *testing.T) func(error) {
+	return func(err error) {
+		t.Errorf("Unexpected dropped message: %s", err)
+	}
+}
+
+func TestSpoolReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	s, err := openSpool(dir, 0)
+	if err != nil {
+		t.Fatalf("Could not open spool: %s", err)
+	}
+
+	for _, content := range []string{"a", "b", "c"} {
+		if err := s.put(Message{Content: StringContent(content)}); err != nil {
+			t.Fatalf("Could not spool message: %s", err)
+		}
+	}
+
+	replayed := []string{}
+
+	// stop the replay after a and b.
+	err = s.replay(func(message Message) error {
+		if len(replayed) == 2 {
+			return errors.New("ERROR")
+		}
+
+		replayed = append(replayed, string(message.Content))
+		return nil
+	}, drop(t))
+	if err == nil {
+		t.Fatalf("Expected an error.")
+	}
+
+	s.close()
+
+	s, err = openSpool(dir, 0)
+	if err != nil {
+		t.Fatalf("Could not open spool: %s", err)
+	}
+
+	defer s.close()
+
+	if stats := s.stats(); stats.Messages != 1 {
+		t.Fatalf("Expected 1 message after reopen, got: %+v", stats)
+	}
+
+	err = s.replay(func(message Message) error {
+		replayed = append(replayed, string(message.Content))
+		return nil
+	}, drop(t))
+	if err != nil {
+		t.Fatalf("Could not replay spool: %s", err)
+	}
+
+	if fmt.Sprint(replayed) != "[a b c]" {
+		t.Fatalf("Expected messages to be replayed once, got: %v", replayed)
+	}
+}
+
+func TestSpoolCorrupt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	s, err := openSpool(dir, 0)
+	if err != nil {
+		t.Fatalf("Could not open spool: %s", err)
+	}
+
+	// a torn write, ending in a newline.
+	if err := ioutil.WriteFile(s.path(0), []byte("{garbage\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = openSpool(dir, 0)
+	if err != nil {
+		t.Fatalf("Could not open spool: %s", err)
+	}
+
+	defer s.close()
+
+	if err := s.put(Message{Content: StringContent("a")}); err != nil {
+		t.Fatalf("Could not spool message: %s", err)
+	}
+
+	replayed := []string{}
+	dropped := 0
+
+	err = s.replay(func(message Message) error {
+		replayed = append(replayed, string(message.Content))
+		return nil
+	}, func(err error) {
+		dropped++
+	})
+	if err != nil {
+		t.Fatalf("Could not replay spool: %s", err)
+	}
+
+	if dropped != 1 || fmt.Sprint(replayed) != "[a]" {
+		t.Fatalf("Expected the corrupt line to be dropped, got %d dropped and %v", dropped, replayed)
+	}
+
+	if !s.empty() {
+		t.Fatalf("Expected an empty spool, got: %+v", s.stats())
+	}
+}
+
+func TestSpoolFull(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	s, err := openSpool(dir, 64)
+	if err != nil {
+		t.Fatalf("Could not open spool: %s", err)
+	}
+
+	defer s.close()
+
+	if err := s.put(Message{Content: StringContent("message")}); err != nil {
+		t.Fatalf("Could not spool message: %s", err)
+	}
+
+	if err := s.put(Message{Content: StringContent("message")}); err != ErrSpoolFull {
+		t.Fatalf("expected error %v, got: %v", ErrSpoolFull, err)
+	}
+}
+
+func TestProduceSpool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	defer func(d time.Duration) {
+		spoolReplayInterval = d
+	}(spoolReplayInterval)
+
+	spoolReplayInterval = 10 * time.Millisecond
+
+	var m sync.Mutex
+
+	produced := []string{}
+
+	ws := &workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			evt, err := putNewEvent.Params.Event()
+			if err != nil {
+				return err
+			}
+
+			content, err := evt.Content()
+			if err != nil {
+				return err
+			}
+
+			m.Lock()
+			produced = append(produced, string(content))
+			m.Unlock()
+			return nil
+		},
+	}
+
+	srvr, err := testServer(ws)
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	addr := srvr.Addr().String()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", addr)),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithSpool(dir, 0),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Millisecond), 2)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	// take down the server, messages should be spooled.
+	srvr.Close()
+
+	for _, s := range []string{"a", "b"} {
+		if eventID, err := w.Produce(context.Background(), Message{Content: StringContent(s)}); err != nil {
+			t.Fatalf("Could not produce message: %s", err.Error())
+		} else if eventID != "" {
+			t.Fatalf("expected an empty EventID for a spooled message, got %s", eventID)
+		}
+	}
+
+	if stats := w.SpoolStats(); stats.Messages != 2 {
+		t.Fatalf("expected 2 spooled messages, got %d", stats.Messages)
+	}
+
+	srvr, err = testServerAt(addr, ws)
+	if err != nil {
+		t.Fatalf("Could not restart test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	for i := 0; w.SpoolStats().Messages > 0; i++ {
+		if i > 100 {
+			t.Fatalf("spool has not been replayed: %+v", w.SpoolStats())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if fmt.Sprint(produced) != "[a b]" {
+		t.Fatalf("messages produced out of order: %v", produced)
+	}
+}
+
+// spooled returns a spool in a new directory with messages.
+func spooled(t *testing.T, contents ...string) string {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := openSpool(dir, 0)
+	if err != nil {
+		t.Fatalf("Could not open spool: %s", err)
+	}
+
+	defer s.close()
+
+	for _, content := range contents {
+		if err := s.put(Message{Content: StringContent(content)}); err != nil {
+			t.Fatalf("Could not spool message: %s", err)
+		}
+	}
+
+	return dir
+}
+
+func TestSpoolReplayClose(t *testing.T) {
+	dir := spooled(t, "a")
+
+	defer os.RemoveAll(dir)
+
+	defer func(d time.Duration) {
+		spoolReplayInterval = d
+	}(spoolReplayInterval)
+
+	spoolReplayInterval = 10 * time.Millisecond
+
+	replaying := make(chan struct{})
+	release := make(chan struct{})
+
+	var once sync.Once
+
+	srvr, err := testServer(&workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			once.Do(func() { close(replaying) })
+
+			<-release
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+	defer close(release)
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithSpool(dir, 0),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	<-replaying
+
+	// closing cancels the message being replayed.
+	w.Close()
+
+	s, err := openSpool(dir, 0)
+	if err != nil {
+		t.Fatalf("Could not open spool: %s", err)
+	}
+
+	defer s.close()
+
+	if stats := s.stats(); stats.Messages != 1 {
+		t.Fatalf("Expected the message to be kept, got: %+v", stats)
+	}
+}
+
+func TestSpoolReplayRejected(t *testing.T) {
+	dir := spooled(t, "a", "b", "c")
+
+	defer os.RemoveAll(dir)
+
+	defer func(d time.Duration) {
+		spoolReplayInterval = d
+	}(spoolReplayInterval)
+
+	spoolReplayInterval = 10 * time.Millisecond
+
+	var m sync.Mutex
+
+	produced := []string{}
+	overloaded := false
+
+	srvr, err := testServer(&workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			evt, err := putNewEvent.Params.Event()
+			if err != nil {
+				return err
+			}
+
+			content, err := evt.Content()
+			if err != nil {
+				return err
+			}
+
+			m.Lock()
+			defer m.Unlock()
+
+			switch {
+			case string(content) == "a" && !overloaded:
+				overloaded = true
+				return exception(rpccapnp.Exception_Type_overloaded, "try again later")
+			case string(content) == "b":
+				return errors.New("invalid event")
+			}
+
+			produced = append(produced, string(content))
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithSpool(dir, 0),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	for i := 0; w.SpoolStats().Messages > 0; i++ {
+		if i > 100 {
+			t.Fatalf("spool has not been replayed: %+v", w.SpoolStats())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	// a is retried, b is rejected and dropped.
+	if fmt.Sprint(produced) != "[a c]" {
+		t.Fatalf("Expected [a c] to be produced, got: %v", produced)
+	}
+}
+
+// exception returns an rpc exception of type typ.
+func exception(typ rpccapnp.Exception_Type, reason string) error {
+	_, seg, _ := capnp.NewMessage(capnp.SingleSegment(nil))
+
+	exc, _ := rpccapnp.NewRootException(seg)
+	exc.SetType(typ)
+	exc.SetReason(reason)
+
+	return rpc.Exception{Exception: exc}
+}
+
+func TestSpoolOpenError(t *testing.T) {
+	f, err := ioutil.TempFile("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Close()
+
+	defer os.Remove(f.Name())
+
+	srvr, err := testServer(&workflowServer{})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	closer := &testCloser{}
+
+	// the spool should be a directory.
+	_, err = New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithCloser(closer),
+		WithSpool(f.Name(), 0),
+	)
+	if err == nil {
+		t.Fatalf("Expected an error.")
+	}
+
+	if n := atomic.LoadInt32(&closer.closed); n != 1 {
+		t.Fatalf("Expected the worker to be closed, got %d", n)
+	}
+}
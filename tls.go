@@ -0,0 +1,176 @@
+package ravenworker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// useTLS returns true when the connection to u should use TLS, for
+// 'capnprotos' urls or for all urls when a TLS config has been set.
+func (w *DefaultWorker) useTLS(u url.URL) bool {
+	return u.Scheme == "capnprotos" || w.tlsConfig != nil
+}
+
+// tlsClient returns a TLS connection to u over conn, after the
+// handshake succeeded. The handshake is aborted when ctx is done.
+func (w *DefaultWorker) tlsClient(ctx context.Context, conn net.Conn, u url.URL) (net.Conn, error) {
+	config := &tls.Config{}
+	if w.tlsConfig != nil {
+		config = w.tlsConfig.Clone()
+	}
+
+	if config.ServerName == "" {
+		config.ServerName = u.Hostname()
+	}
+
+	tlsConn := tls.Client(conn, config)
+
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- tlsConn.Handshake()
+	}()
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	case <-ctx.Done():
+		// closing the conn stops the handshake.
+		conn.Close()
+		<-errc
+		return nil, ctx.Err()
+	}
+
+	return tlsConn, nil
+}
+
+// tlsConfigOrNew returns the TLS config of c, creating it when needed.
+func (c *Config) tlsConfigOrNew() *tls.Config {
+	if c.tlsConfig == nil {
+		c.tlsConfig = &tls.Config{}
+	}
+
+	return c.tlsConfig
+}
+
+//WithTLSConfig connects to the Raven server using TLS with config,
+// for all urls. Use 'capnprotos' urls to use TLS with the default
+// config.
+func WithTLSConfig(config *tls.Config) OptionFunc {
+	return func(c *Config) error {
+		if config == nil {
+			return fmt.Errorf("WithTLSConfig called with <nil> config")
+		}
+		c.tlsConfig = config.Clone()
+		return nil
+	}
+}
+
+//WithCAFile trusts the PEM encoded certificates in file to verify the
+// Raven server, instead of the system roots. It enables TLS for all urls.
+func WithCAFile(file string) (OptionFunc, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+
+	return func(c *Config) error {
+		c.tlsConfigOrNew().RootCAs = pool
+		return nil
+	}, nil
+}
+
+//WithClientCertificate authenticates the worker to the Raven server
+// with the PEM encoded certificate and key, mutual TLS. The files are
+// loaded again on reconnect when they have been changed, so the
+// certificate can be renewed without restarting the worker. It enables
+// TLS for all urls.
+func WithClientCertificate(certFile, keyFile string) (OptionFunc, error) {
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	// fail early on invalid files.
+	if _, err := r.GetClientCertificate(nil); err != nil {
+		return nil, err
+	}
+
+	return func(c *Config) error {
+		c.tlsConfigOrNew().GetClientCertificate = r.GetClientCertificate
+		return nil
+	}, nil
+}
+
+// certReloader loads a certificate and key, and loads them again when
+// the files have been changed.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	m       sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// GetClientCertificate returns the current certificate, it is used as
+// tls.Config.GetClientCertificate.
+func (r *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	modTime, err := r.lastModified()
+	if err != nil && r.cert == nil {
+		return nil, err
+	} else if err != nil || !modTime.After(r.modTime) {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil && r.cert == nil {
+		return nil, err
+	} else if err != nil {
+		// the files may be written right now, keep using the
+		// current certificate.
+		return r.cert, nil
+	}
+
+	r.cert = &cert
+	r.modTime = modTime
+
+	return r.cert, nil
+}
+
+// lastModified returns the latest modification time of the files.
+func (r *certReloader) lastModified() (time.Time, error) {
+	var modTime time.Time
+
+	for _, file := range []string{r.certFile, r.keyFile} {
+		fi, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if fi.ModTime().After(modTime) {
+			modTime = fi.ModTime()
+		}
+	}
+
+	return modTime, nil
+}
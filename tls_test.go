@@ -0,0 +1,338 @@
+package ravenworker
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dutchsec/raven-worker/workflow"
+	context "golang.org/x/net/context"
+)
+
+// testCA is a locally generated certificate authority.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "raven test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue returns a PEM encoded certificate and key for name.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testServerTLS starts a test server using TLS, requiring a client
+// certificate signed by ca when clientCA is true.
+func testServerTLS(t *testing.T, ca *testCA, clientCA bool, ws *workflowServer) net.Listener {
+	certPEM, keyPEM := ca.issue(t, "raven", x509.ExtKeyUsageServerAuth)
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if clientCA {
+		pool := x509.NewCertPool()
+		pool.AddCert(ca.cert)
+
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	nl, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err)
+	}
+
+	return testServe(nl, ws)
+}
+
+func TestTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t)
+
+	srvr := testServerTLS(t, ca, false, &workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			return nil
+		},
+	})
+
+	defer srvr.Close()
+
+	withCA, err := WithCAFile(writeFile(t, dir, "ca.pem", ca.pem))
+	if err != nil {
+		t.Fatalf("Could not load CA: %s", err)
+	}
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnprotos://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		withCA,
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	if _, err := w.Produce(context.Background(), TestProduceMessage); err != nil {
+		t.Fatalf("Could not produce message: %s", err.Error())
+	}
+}
+
+func TestTLSUntrusted(t *testing.T) {
+	ca := newTestCA(t)
+
+	srvr := testServerTLS(t, ca, false, &workflowServer{})
+
+	defer srvr.Close()
+
+	_, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnprotos://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+	)
+	if err == nil {
+		t.Fatalf("Expected certificate signed by unknown authority to fail")
+	}
+}
+
+func TestTLSHandshakeContext(t *testing.T) {
+	nl, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer nl.Close()
+
+	// accept connections, but never answer the handshake.
+	go func() {
+		for {
+			conn, err := nl.Accept()
+			if err != nil {
+				return
+			}
+
+			defer conn.Close()
+		}
+	}()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnprotos://%s", nl.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithLazyConnect(),
+		WithBackOff(StopBackOff),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	if _, err := w.Produce(ctx, TestProduceMessage); err == nil {
+		t.Fatalf("Expected the handshake to fail")
+	} else if d := time.Since(start); d > time.Second {
+		t.Fatalf("Expected Produce to return after the deadline, took %s", d)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t)
+
+	srvr := testServerTLS(t, ca, true, &workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			return nil
+		},
+	})
+
+	defer srvr.Close()
+
+	certPEM, keyPEM := ca.issue(t, "worker", x509.ExtKeyUsageClientAuth)
+
+	withCert, err := WithClientCertificate(writeFile(t, dir, "cert.pem", certPEM), writeFile(t, dir, "key.pem", keyPEM))
+	if err != nil {
+		t.Fatalf("Could not load client certificate: %s", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithTLSConfig(&tls.Config{RootCAs: pool}),
+		withCert,
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	if _, err := w.Produce(context.Background(), TestProduceMessage); err != nil {
+		t.Fatalf("Could not produce message: %s", err.Error())
+	}
+}
+
+func TestCertReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t)
+
+	certPEM, keyPEM := ca.issue(t, "worker-1", x509.ExtKeyUsageClientAuth)
+
+	r := &certReloader{
+		certFile: writeFile(t, dir, "cert.pem", certPEM),
+		keyFile:  writeFile(t, dir, "key.pem", keyPEM),
+	}
+
+	commonName := func() string {
+		cert, err := r.GetClientCertificate(nil)
+		if err != nil {
+			t.Fatalf("Could not get certificate: %s", err)
+		}
+
+		parsed, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return parsed.Subject.CommonName
+	}
+
+	if name := commonName(); name != "worker-1" {
+		t.Fatalf("Expected worker-1, got %s", name)
+	}
+
+	// renew the certificate.
+	certPEM, keyPEM = ca.issue(t, "worker-2", x509.ExtKeyUsageClientAuth)
+
+	writeFile(t, dir, "cert.pem", certPEM)
+	writeFile(t, dir, "key.pem", keyPEM)
+
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(r.certFile, later, later)
+	os.Chtimes(r.keyFile, later, later)
+
+	if name := commonName(); name != "worker-2" {
+		t.Fatalf("Expected renewed certificate worker-2, got %s", name)
+	}
+}
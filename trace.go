@@ -0,0 +1,319 @@
+package ravenworker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MetaTraceParent is the metadata key of the W3C traceparent.
+	MetaTraceParent = "traceparent"
+
+	// MetaTraceState is the metadata key of the W3C tracestate.
+	MetaTraceState = "tracestate"
+)
+
+// SpanContext identifies a span within a trace, as propagated using
+// W3C trace context.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+	State   string // the tracestate, passed on unchanged.
+}
+
+// IsValid returns true when both the trace and span id are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceParent returns the W3C traceparent of sc.
+func (sc SpanContext) TraceParent() string {
+	return fmt.Sprintf("00-%x-%x-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// ParseTraceParent parses a W3C traceparent, the tracestate is set
+// to state.
+func ParseTraceParent(traceParent, state string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, fmt.Errorf("invalid traceparent: %s", traceParent)
+	} else if len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, fmt.Errorf("unsupported traceparent version: %s", parts[0])
+	} else if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, fmt.Errorf("invalid traceparent: %s", traceParent)
+	}
+
+	sc := SpanContext{
+		State: state,
+	}
+
+	var flags [1]byte
+
+	for _, field := range []struct {
+		s   string
+		dst []byte
+	}{
+		{parts[1], sc.TraceID[:]},
+		{parts[2], sc.SpanID[:]},
+		{parts[3], flags[:]},
+	} {
+		if len(field.s) != hex.EncodedLen(len(field.dst)) {
+			return SpanContext{}, fmt.Errorf("invalid traceparent: %s", traceParent)
+		} else if _, err := hex.Decode(field.dst, []byte(field.s)); err != nil {
+			return SpanContext{}, fmt.Errorf("invalid traceparent: %s", traceParent)
+		}
+	}
+
+	sc.Flags = flags[0]
+
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent: %s", traceParent)
+	}
+
+	return sc, nil
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a copy of ctx with sc as the current
+// span, new spans and produced messages will be part of its trace.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the current span of ctx.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// TraceContext returns the span context propagated in the metadata of
+// message, Run continues the trace of every message it handles.
+//
+//     if sc, ok := TraceContext(message); ok {
+//         ctx = ContextWithSpanContext(ctx, sc)
+//     }
+func TraceContext(message Message) (SpanContext, bool) {
+	var traceParent, state string
+
+	for _, md := range message.MetaData {
+		switch md.Key {
+		case MetaTraceParent:
+			traceParent = md.Value
+		case MetaTraceState:
+			state = md.Value
+		}
+	}
+
+	if traceParent == "" {
+		return SpanContext{}, false
+	}
+
+	sc, err := ParseTraceParent(traceParent, state)
+	if err != nil {
+		return SpanContext{}, false
+	}
+
+	return sc, true
+}
+
+// withTraceContext returns a copy of metadata with the traceparent and
+// tracestate of sc, replacing existing ones.
+func withTraceContext(metadata []Metadata, sc SpanContext) []Metadata {
+	result := make([]Metadata, 0, len(metadata)+2)
+
+	for _, md := range metadata {
+		if md.Key == MetaTraceParent || md.Key == MetaTraceState {
+			continue
+		}
+
+		result = append(result, md)
+	}
+
+	result = append(result, Metadata{Key: MetaTraceParent, Value: sc.TraceParent()})
+
+	if sc.State != "" {
+		result = append(result, Metadata{Key: MetaTraceState, Value: sc.State})
+	}
+
+	return result
+}
+
+// Span is a finished span, as passed to the SpanExporter.
+type Span struct {
+	Name         string            `json:"name"`
+	TraceID      string            `json:"trace_id"`
+	SpanID       string            `json:"span_id"`
+	ParentSpanID string            `json:"parent_span_id,omitempty"`
+	Start        time.Time         `json:"start"`
+	End          time.Time         `json:"end"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// SpanExporter receives every finished span, it should not block.
+type SpanExporter interface {
+	ExportSpan(span Span) error
+}
+
+// SpanExporterFunc is a function implementing SpanExporter.
+type SpanExporterFunc func(span Span) error
+
+func (fn SpanExporterFunc) ExportSpan(span Span) error {
+	return fn(span)
+}
+
+// StdoutExporter writes spans as json lines to stdout.
+var StdoutExporter = WriterExporter(os.Stdout)
+
+// WriterExporter returns a SpanExporter writing spans as json lines
+// to w.
+func WriterExporter(w io.Writer) SpanExporter {
+	var m sync.Mutex
+
+	return SpanExporterFunc(func(span Span) error {
+		data, err := json.Marshal(span)
+		if err != nil {
+			return err
+		}
+
+		m.Lock()
+		defer m.Unlock()
+
+		_, err = w.Write(append(data, '\n'))
+		return err
+	})
+}
+
+// SpanCollector keeps the exported spans in memory, it is a stand-in
+// for a collector in tests.
+type SpanCollector struct {
+	m     sync.Mutex
+	spans []Span
+}
+
+func (c *SpanCollector) ExportSpan(span Span) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.spans = append(c.spans, span)
+	return nil
+}
+
+// Spans returns the collected spans, in the order they finished.
+func (c *SpanCollector) Spans() []Span {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return append([]Span{}, c.spans...)
+}
+
+// span is a running span, a nil span is a noop so calls don't have to
+// check whether tracing is enabled.
+type span struct {
+	exporter SpanExporter
+	log      Logger
+
+	sc     SpanContext
+	parent [8]byte
+
+	name       string
+	start      time.Time
+	attributes map[string]string
+}
+
+// startSpan starts a span named name, as child of the current span of
+// ctx or as a new trace. It returns a copy of ctx with the new span as
+// the current span.
+func (c *DefaultWorker) startSpan(ctx context.Context, name string) (context.Context, *span) {
+	if c.exporter == nil {
+		return ctx, nil
+	}
+
+	s := &span{
+		exporter: c.exporter,
+		log:      c.log,
+		name:     name,
+		start:    time.Now(),
+		attributes: map[string]string{
+			"flow_id":   c.FlowID.String(),
+			"worker_id": c.WorkerID.String(),
+		},
+	}
+
+	if parent, ok := SpanContextFromContext(ctx); ok {
+		s.sc = parent
+		s.parent = parent.SpanID
+	} else {
+		s.sc.Flags = 0x01 // sampled
+		rand.Read(s.sc.TraceID[:])
+	}
+
+	rand.Read(s.sc.SpanID[:])
+
+	return s.with(ctx), s
+}
+
+// with returns a copy of ctx with s as the current span.
+func (s *span) with(ctx context.Context) context.Context {
+	if s == nil {
+		return ctx
+	}
+
+	return ContextWithSpanContext(ctx, s.sc)
+}
+
+// set sets the attribute key to value.
+func (s *span) set(key, value string) {
+	if s == nil {
+		return
+	}
+
+	s.attributes[key] = value
+}
+
+// inject returns a copy of metadata with the trace context of s.
+func (s *span) inject(metadata []Metadata) []Metadata {
+	if s == nil {
+		return metadata
+	}
+
+	return withTraceContext(metadata, s.sc)
+}
+
+// end finishes the span and exports it.
+func (s *span) end(err error) {
+	if s == nil {
+		return
+	}
+
+	exported := Span{
+		Name:       s.name,
+		TraceID:    hex.EncodeToString(s.sc.TraceID[:]),
+		SpanID:     hex.EncodeToString(s.sc.SpanID[:]),
+		Start:      s.start,
+		End:        time.Now(),
+		Attributes: s.attributes,
+	}
+
+	if s.parent != [8]byte{} {
+		exported.ParentSpanID = hex.EncodeToString(s.parent[:])
+	}
+
+	if err != nil {
+		exported.Error = err.Error()
+	}
+
+	if err := s.exporter.ExportSpan(exported); err != nil {
+		s.log.Errorf("Could not export span %s: %s", s.name, err)
+	}
+}
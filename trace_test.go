@@ -0,0 +1,216 @@
+package ravenworker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/dutchsec/raven-worker/workflow"
+	"github.com/gofrs/uuid"
+	context "golang.org/x/net/context"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceParent(t *testing.T) {
+	sc, err := ParseTraceParent(testTraceParent, "vendor=value")
+	if err != nil {
+		t.Fatalf("Could not parse traceparent: %s", err)
+	}
+
+	if s := sc.TraceParent(); s != testTraceParent {
+		t.Fatalf("Expected traceparent %s, got %s", testTraceParent, s)
+	}
+
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceParent(s, ""); err == nil {
+			t.Errorf("Expected traceparent %q to be invalid", s)
+		}
+	}
+}
+
+// metaValue returns the value of key in the metadata of event.
+func metaValue(t *testing.T, event workflow.Event, key string) string {
+	meta, err := event.Meta()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, md := range transformMeta(meta) {
+		if md.Key == key {
+			return md.Value
+		}
+	}
+
+	return ""
+}
+
+func TestRunTracing(t *testing.T) {
+	ackID, _ := uuid.NewV4()
+	eventID, _ := uuid.NewV4()
+
+	var (
+		m                         sync.Mutex
+		jobs                      int
+		produced, acked           string
+		producedState, ackedState string
+	)
+
+	srvr, err := testServer(&workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			m.Lock()
+			defer m.Unlock()
+
+			// hand out a single job.
+			if jobs++; jobs > 1 {
+				return errors.New("item not found")
+			}
+
+			getJob.Results.SetAckID(ackID.Bytes())
+			getJob.Results.SetEventID(eventID.Bytes())
+			return nil
+		},
+		getEvent: func(getEvent workflow.Connection_getEvent) error {
+			evt, err := getEvent.Results.NewEvent()
+			if err != nil {
+				return err
+			}
+
+			meta, err := evt.NewMeta(2)
+			if err != nil {
+				return err
+			}
+
+			meta.At(0).SetKey(MetaTraceParent)
+			meta.At(0).SetValue(testTraceParent)
+			meta.At(1).SetKey(MetaTraceState)
+			meta.At(1).SetValue("vendor=value")
+
+			return getEvent.Results.SetEvent(evt)
+		},
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			event, err := putNewEvent.Params.Event()
+			if err != nil {
+				return err
+			}
+
+			m.Lock()
+			produced = metaValue(t, event, MetaTraceParent)
+			producedState = metaValue(t, event, MetaTraceState)
+			m.Unlock()
+			return nil
+		},
+		ackJob: func(ackJob workflow.Connection_ackJob) error {
+			event, err := ackJob.Params.Event()
+			if err != nil {
+				return err
+			}
+
+			m.Lock()
+			acked = metaValue(t, event, MetaTraceParent)
+			ackedState = metaValue(t, event, MetaTraceState)
+			m.Unlock()
+
+			ackJob.Results.SetAcked(true)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	collector := &SpanCollector{}
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		MustWithConsumeTimeout("100ms"),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)
+		}),
+		WithTracing(collector),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	err = w.Run(context.Background(), func(ctx context.Context, message Message) (Result, error) {
+		if _, err := w.Produce(ctx, NewMessage()); err != nil {
+			return Result{}, err
+		}
+
+		return Result{Message: &message}, nil
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %s", err.Error())
+	}
+
+	spans := map[string]Span{}
+	for _, span := range collector.Spans() {
+		spans[span.Name] = span
+	}
+
+	for _, name := range []string{"raven.consume", "raven.get", "raven.handle", "raven.produce", "raven.ack"} {
+		if _, ok := spans[name]; !ok {
+			t.Fatalf("Expected span %s, got %v", name, collector.Spans())
+		}
+	}
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	handle := spans["raven.handle"]
+	if handle.TraceID != traceID || handle.ParentSpanID != "00f067aa0ba902b7" {
+		t.Fatalf("Expected handle span to continue the trace of the message, got %+v", handle)
+	} else if handle.Attributes["event_id"] != eventID.String() {
+		t.Fatalf("Expected event_id attribute %s, got %s", eventID.String(), handle.Attributes["event_id"])
+	}
+
+	for _, name := range []string{"raven.produce", "raven.ack"} {
+		if span := spans[name]; span.TraceID != traceID || span.ParentSpanID != handle.SpanID {
+			t.Fatalf("Expected %s span to be a child of the handle span, got %+v", name, span)
+		}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if want := fmt.Sprintf("00-%s-%s-01", traceID, spans["raven.produce"].SpanID); produced != want {
+		t.Fatalf("Expected produced traceparent %s, got %s", want, produced)
+	} else if want := fmt.Sprintf("00-%s-%s-01", traceID, spans["raven.ack"].SpanID); acked != want {
+		t.Fatalf("Expected acked traceparent %s, got %s", want, acked)
+	} else if producedState != "vendor=value" || ackedState != "vendor=value" {
+		t.Fatalf("Expected tracestate to be propagated, got %q and %q", producedState, ackedState)
+	}
+}
+
+func TestWriterExporter(t *testing.T) {
+	var sb strings.Builder
+
+	exporter := WriterExporter(&sb)
+
+	if err := exporter.ExportSpan(Span{Name: "raven.get", TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"}); err != nil {
+		t.Fatalf("Could not export span: %s", err)
+	}
+
+	if s := sb.String(); !strings.HasPrefix(s, `{"name":"raven.get","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`) || !strings.HasSuffix(s, "\n") {
+		t.Fatalf("Unexpected output: %s", s)
+	}
+}
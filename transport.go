@@ -0,0 +1,41 @@
+package ravenworker
+
+import (
+	"net"
+	"sync/atomic"
+)
+
+// transport wraps the network connection of the rpc connection and
+// remembers if a read or write has failed, so a broken connection is
+// detected before the rpc connection has been torn down.
+type transport struct {
+	net.Conn
+
+	broken int32
+}
+
+func (t *transport) Read(p []byte) (int, error) {
+	n, err := t.Conn.Read(p)
+	if err != nil {
+		atomic.StoreInt32(&t.broken, 1)
+	}
+	return n, err
+}
+
+func (t *transport) Write(p []byte) (int, error) {
+	n, err := t.Conn.Write(p)
+	if err != nil {
+		atomic.StoreInt32(&t.broken, 1)
+	}
+	return n, err
+}
+
+func (t *transport) Close() error {
+	atomic.StoreInt32(&t.broken, 1)
+	return t.Conn.Close()
+}
+
+// isBroken returns true once the connection failed or has been closed.
+func (t *transport) isBroken() bool {
+	return atomic.LoadInt32(&t.broken) == 1
+}
@@ -3,6 +3,7 @@ package ravenworker
 import (
 	"context"
 	"net"
+	"net/http"
 	"sync"
 	"time"
 
@@ -13,43 +14,144 @@ import (
 
 type Worker interface {
 	Consume(ctx context.Context) (Reference, error)
-	Get(Reference) (Message, error)
-	Ack(Reference, ...AckOptionFunc) error
-	Produce(Message) error
+	Get(ctx context.Context, ref Reference) (Message, error)
+	Ack(ctx context.Context, ref Reference, options ...AckOptionFunc) error
+	Nack(ctx context.Context, ref Reference, options ...NackOptionFunc) error
+	ExtendLease(ctx context.Context, ref Reference) (time.Duration, error)
+	KeepAlive(ctx context.Context, ref Reference, interval time.Duration) (context.Context, context.CancelFunc)
+	Produce(ctx context.Context, message Message) (EventID, error)
+	ProduceBatch(ctx context.Context, messages []Message) ([]ProduceResult, error)
+	ProduceAsync(ctx context.Context, message Message) (*ProduceFuture, error)
+	Flush(ctx context.Context) error
+	SpoolStats() SpoolStats
+	Run(ctx context.Context, handler Handler) error
+	RunUntilSignal(ctx context.Context, handler Handler) error
+	WaitReady(ctx context.Context) error
+	Status() Status
+	HealthHandler() http.Handler
+	MetricsHandler() http.Handler
 	Close() error
 }
 
 type DefaultWorker struct {
+	intake   int64 // number of consumed messages, first for 64-bit alignment.
+	reserved int64 // number of consumed messages and calls to Consume in progress.
+
 	Config
 
 	w workflow.Connection
 
-	m sync.Mutex
+	connected workflow.Workflow_connect_Results_Promise // resolves when the server accepted the worker.
+
+	transport *transport
+	rpcconn   *rpc.Conn
+
+	outbox *outbox
+
+	attempts *attempts
+
+	counters *counters
+	metrics  *metrics
+
+	health         *http.Server
+	healthListener net.Listener
+
+	spool      *spool
+	stopReplay context.CancelFunc
+	replayDone chan struct{}
+
+	closeOnce sync.Once
+
+	dialing chan struct{} // holds the caller that is reconnecting.
+
+	m      sync.Mutex
+	closed bool
 
 	connectionCounter int
+
+	url string // the url of the current connection.
 }
 
+// Close produces the messages in the outbox, closes the connection to
+// the Raven server and the registered closers. It is safe to call Close
+// more than once, all other methods return ErrClosed afterwards.
 func (w *DefaultWorker) Close() error {
+	w.closeOnce.Do(w.close)
+	return nil
+}
+
+func (w *DefaultWorker) close() {
+	// produce the remaining messages before closing the logger.
+	w.outbox.close()
+
+	if w.spool != nil {
+		w.stopReplay()
+		<-w.replayDone
+
+		w.spool.close()
+	}
+
+	w.m.Lock()
+	w.closed = true
+
+	if w.rpcconn != nil {
+		w.rpcconn.Close()
+	}
+
+	// the rpc conn closes the transport, unless it is hanging.
+	if w.transport != nil {
+		w.transport.Close()
+	}
+
+	w.rpcconn = nil
+	w.transport = nil
+	w.w = workflow.Connection{}
+	w.m.Unlock()
+
+	if w.health != nil {
+		w.health.Close()
+	}
+
 	for _, c := range w.closers {
 		c.Close()
 	}
-	return nil
 }
 
-func (w *DefaultWorker) connect() error {
+// isClosed returns true when Close has been called.
+func (w *DefaultWorker) isClosed() bool {
 	w.m.Lock()
 	defer w.m.Unlock()
 
+	return w.closed
+}
+
+func (w *DefaultWorker) connect() error {
+	return w.dial(context.Background())
+}
+
+// dial connects to the next url in round robin order and replaces
+// the current connection. The caller must hold the dialing slot, w.m
+// is only held to swap the connection, so a slow server doesn't block
+// the callers using the current connection or Status.
+func (w *DefaultWorker) dial(ctx context.Context) error {
+	w.m.Lock()
 	u := w.urls[w.connectionCounter%len(w.urls)]
 
+	// always move on to the next url, so a failing server
+	// will fail over to the next one on retry.
+	w.connectionCounter++
+	w.m.Unlock()
+
 	w.log.Infof("Connecting to rpc server: %v", u.String())
 
-	conn, err := net.Dial("tcp", u.Host)
+	conn, err := w.dialURL(ctx, u)
 	if err != nil {
 		return err
 	}
 
-	rpcconn := rpc.NewConn(rpc.StreamTransport(conn))
+	t := &transport{Conn: conn}
+
+	rpcconn := rpc.NewConn(rpc.StreamTransport(t))
 
 	client := rpcconn.Bootstrap(context.Background())
 
@@ -64,13 +166,123 @@ func (w *DefaultWorker) connect() error {
 			return err
 		}
 
+		if w.authToken == "" {
+		} else if err := params.SetToken(w.authToken); err != nil {
+			return err
+		}
+
 		return nil
 	})
 
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if w.closed {
+		rpcconn.Close()
+		t.Close()
+		return ErrClosed
+	}
+
+	if w.rpcconn != nil {
+		w.rpcconn.Close()
+	}
+
 	w.w = promise.Connection()
+	w.connected = promise
+	w.transport = t
+	w.rpcconn = rpcconn
+	w.url = u.String()
 
-	w.connectionCounter++
-	return err
+	return nil
+}
+
+// current returns the workflow connection, ok is false when the rpc
+// connection has been lost.
+func (w *DefaultWorker) current() (conn workflow.Connection, ok bool, err error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if w.closed {
+		return workflow.Connection{}, false, ErrClosed
+	}
+
+	if w.disconnected() {
+		return workflow.Connection{}, false, nil
+	}
+
+	return w.w, true, nil
+}
+
+// connection returns the workflow connection. If the rpc connection
+// has been lost, it will reconnect first, one caller at a time and
+// until ctx is done. ErrClosed is returned after Close.
+func (w *DefaultWorker) connection(ctx context.Context) (workflow.Connection, error) {
+	if conn, ok, err := w.current(); err != nil {
+		return workflow.Connection{}, err
+	} else if ok {
+		return conn, nil
+	}
+
+	select {
+	case w.dialing <- struct{}{}:
+	case <-ctx.Done():
+		return workflow.Connection{}, &Error{Kind: ErrConnectionLost, Err: ctx.Err()}
+	}
+
+	defer func() { <-w.dialing }()
+
+	// another caller may have reconnected while we were waiting.
+	if conn, ok, err := w.current(); err != nil {
+		return workflow.Connection{}, err
+	} else if ok {
+		return conn, nil
+	}
+
+	// with WithLazyConnect there is no connection to lose yet.
+	w.m.Lock()
+	lost := w.rpcconn != nil
+	w.m.Unlock()
+
+	if lost {
+		w.log.Infof("Connection to rpc server lost, reconnecting.")
+	}
+
+	if err := w.dial(ctx); err == ErrClosed {
+		return workflow.Connection{}, err
+	} else if err != nil {
+		return workflow.Connection{}, &Error{Kind: ErrConnectionLost, Err: err}
+	}
+
+	if conn, ok, err := w.current(); err != nil {
+		return workflow.Connection{}, err
+	} else if ok {
+		return conn, nil
+	}
+
+	return workflow.Connection{}, &Error{Kind: ErrConnectionLost, Err: rpc.ErrConnClosed}
+}
+
+// disconnected returns true when there is no live rpc connection.
+// The caller must hold w.m.
+func (w *DefaultWorker) disconnected() bool {
+	if w.rpcconn == nil || w.transport.isBroken() {
+		return true
+	}
+
+	select {
+	case <-w.rpcconn.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// connectionLost returns true when the rpc connection has been lost.
+func (w *DefaultWorker) connectionLost() bool {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	return w.disconnected()
 }
 
 // New returns a new configured Raven Worker client
@@ -79,7 +291,12 @@ func New(opts ...OptionFunc) (Worker, error) {
 		newBackOff: func() backoff.BackOff {
 			return backoff.WithMaxRetries(backoff.NewExponentialBackOff(), 5)
 		},
-		consumeTimeout: 60 * time.Second,
+		consumeTimeout:  60 * time.Second,
+		readyThreshold:  5 * time.Minute,
+		shutdownTimeout: 30 * time.Second,
+		concurrency:     1,
+		outboxSize:      100,
+		outboxWorkers:   1,
 	}
 
 	for _, optFn := range opts {
@@ -92,12 +309,55 @@ func New(opts ...OptionFunc) (Worker, error) {
 		return nil, err
 	}
 
+	// CustomEnvironment doesn't set a logger.
+	if c.log == nil {
+		c.log = NewFieldLogger(DefaultLogger)
+	}
+
+	fields := []Field{{"flow_id", c.FlowID.String()}}
+
+	// NewDefaultLogger adds the worker_id already.
+	if l, ok := c.log.(*defaultLogger); !ok || l.workerID == "" {
+		fields = append(fields, Field{"worker_id", c.WorkerID.String()})
+	}
+
+	c.log = c.log.With(fields...)
+
 	w := &DefaultWorker{
-		Config: c,
+		Config:   c,
+		attempts: newAttempts(attemptsSize),
+		counters: &counters{},
+		metrics:  newMetrics(),
+		dialing:  make(chan struct{}, 1),
+	}
+
+	// a worker that just started is ready.
+	w.counters.activity()
+
+	w.outbox = newOutbox(c.outboxSize, c.outboxWorkers, func(message Message) (EventID, error) {
+		return w.Produce(context.Background(), message)
+	})
+
+	// with WithLazyConnect the first call will connect, using the
+	// backoff strategy.
+	if c.lazyConnect {
+	} else if err := w.connect(); err != nil {
+		return nil, err
+	}
+
+	if c.spoolDir != "" {
+		s, err := openSpool(c.spoolDir, c.spoolMaxSize)
+		if err != nil {
+			w.Close()
+			return nil, err
+		}
+
+		w.startSpool(s)
 	}
 
-	// TODO: just start and have backoff handle
-	if err := w.connect(); err != nil {
+	if c.healthAddr == "" {
+	} else if err := w.startHealth(c.healthAddr); err != nil {
+		w.Close()
 		return nil, err
 	}
 
@@ -1,13 +1,21 @@
 package ravenworker
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
 
 	"github.com/dutchsec/raven-worker/workflow"
 	"github.com/gofrs/uuid"
+	context "golang.org/x/net/context"
 	"zombiezen.com/go/capnproto2/rpc"
 )
 
@@ -35,6 +43,22 @@ func MustWithWorkerID(workerID string) OptionFunc {
 	return fn
 }
 
+func MustWithConsumeTimeout(s string) OptionFunc {
+	fn, err := WithConsumeTimeout(s)
+	if err != nil {
+		panic(err)
+	}
+	return fn
+}
+
+func MustWithKeepAlive(s string) OptionFunc {
+	fn, err := WithKeepAlive(s)
+	if err != nil {
+		panic(err)
+	}
+	return fn
+}
+
 func MustWithLogger(l Logger) OptionFunc {
 	fn, _ := WithLogger(l)
 	return fn
@@ -53,14 +77,42 @@ func TestMain(m *testing.M) {
 }
 
 type workflowServer struct {
-	getEvent       func(getEvent workflow.Workflow_getEvent) error
-	getJob         func(getJob workflow.Workflow_getJob) error
-	ackJob         func(ackJob workflow.Workflow_ackJob) error
-	putEvent       func(putEvent workflow.Workflow_putEvent) error
-	getLatestEvent func(getLatestEvent workflow.Workflow_getLatestEventID) error
+	connect     func(connect workflow.Workflow_connect) error
+	getEvent    func(getEvent workflow.Connection_getEvent) error
+	getJob      func(getJob workflow.Connection_getJob) error
+	ackJob      func(ackJob workflow.Connection_ackJob) error
+	putEvent    func(putEvent workflow.Connection_putEvent) error
+	putNewEvent func(putNewEvent workflow.Connection_putNewEvent) error
+	extendJob   func(extendJob workflow.Connection_extendJob) error
+	nackJob     func(nackJob workflow.Connection_nackJob) error
+}
+
+func (w *workflowServer) Connect(connect workflow.Workflow_connect) error {
+	if w.connect == nil {
+	} else if err := w.connect(connect); err != nil {
+		return err
+	}
+
+	return connect.Results.SetConnection(workflow.Connection_ServerToClient(w))
 }
 
-func (w *workflowServer) PutEvent(putEvent workflow.Workflow_putEvent) error {
+func (w *workflowServer) GetEventAllVersions(e workflow.Workflow_getEventAllVersions) error {
+	return nil
+}
+
+func (w *workflowServer) GetQueue(e workflow.Workflow_getQueue) error {
+	return nil
+}
+
+func (w *workflowServer) GetQueues(e workflow.Workflow_getQueues) error {
+	return nil
+}
+
+func (w *workflowServer) GetLatestEventID(e workflow.Workflow_getLatestEventID) error {
+	return nil
+}
+
+func (w *workflowServer) PutEvent(putEvent workflow.Connection_putEvent) error {
 	if w.putEvent != nil {
 		return w.putEvent(putEvent)
 	}
@@ -68,11 +120,15 @@ func (w *workflowServer) PutEvent(putEvent workflow.Workflow_putEvent) error {
 	return fmt.Errorf("putEvent not configured")
 }
 
-func (w *workflowServer) PutNewEvent(e workflow.Workflow_putNewEvent) error {
-	return nil
+func (w *workflowServer) PutNewEvent(putNewEvent workflow.Connection_putNewEvent) error {
+	if w.putNewEvent != nil {
+		return w.putNewEvent(putNewEvent)
+	}
+
+	return fmt.Errorf("putNewEvent not configured")
 }
 
-func (w *workflowServer) AckJob(ackJob workflow.Workflow_ackJob) error {
+func (w *workflowServer) AckJob(ackJob workflow.Connection_ackJob) error {
 	if w.ackJob != nil {
 		return w.ackJob(ackJob)
 	}
@@ -80,7 +136,7 @@ func (w *workflowServer) AckJob(ackJob workflow.Workflow_ackJob) error {
 	return fmt.Errorf("ackJob not configured")
 }
 
-func (w *workflowServer) GetJob(getJob workflow.Workflow_getJob) error {
+func (w *workflowServer) GetJob(getJob workflow.Connection_getJob) error {
 	if w.getJob != nil {
 		return w.getJob(getJob)
 	}
@@ -88,15 +144,23 @@ func (w *workflowServer) GetJob(getJob workflow.Workflow_getJob) error {
 	return fmt.Errorf("getJob not configured")
 }
 
-func (w *workflowServer) GetLatestEventID(getLatestEvent workflow.Workflow_getLatestEventID) error {
-	if w.getLatestEvent != nil {
-		return w.getLatestEvent(getLatestEvent)
+func (w *workflowServer) ExtendJob(extendJob workflow.Connection_extendJob) error {
+	if w.extendJob != nil {
+		return w.extendJob(extendJob)
 	}
 
-	return fmt.Errorf("getLatestEvent not configured")
+	return fmt.Errorf("extendJob not configured")
 }
 
-func (w *workflowServer) GetEvent(getEvent workflow.Workflow_getEvent) error {
+func (w *workflowServer) NackJob(nackJob workflow.Connection_nackJob) error {
+	if w.nackJob != nil {
+		return w.nackJob(nackJob)
+	}
+
+	return fmt.Errorf("nackJob not configured")
+}
+
+func (w *workflowServer) GetEvent(getEvent workflow.Connection_getEvent) error {
 	if w.getEvent != nil {
 		return w.getEvent(getEvent)
 	}
@@ -104,36 +168,367 @@ func (w *workflowServer) GetEvent(getEvent workflow.Workflow_getEvent) error {
 	return fmt.Errorf("getEvent not configured")
 }
 
-func (w *workflowServer) GetEventAllVersions(e workflow.Workflow_getEventAllVersions) error {
-	return nil
+// testListener closes all accepted connections when closed, to
+// simulate a server going down.
+type testListener struct {
+	net.Listener
+
+	m     sync.Mutex
+	conns []net.Conn
+}
+
+func (l *testListener) Close() error {
+	err := l.Listener.Close()
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	for _, conn := range l.conns {
+		conn.Close()
+	}
+
+	return err
 }
 
 func testServer(ws *workflowServer) (net.Listener, error) {
-	l, err := net.Listen("tcp", "127.0.0.1:0")
+	return testServerAt("127.0.0.1:0", ws)
+}
+
+// testServerAt starts a test server listening on addr.
+func testServerAt(addr string, ws *workflowServer) (net.Listener, error) {
+	nl, err := net.Listen("tcp", addr)
 	if err != nil {
 		return nil, fmt.Errorf("error starting listener: %s", err.Error())
 	}
 
+	return testServe(nl, ws), nil
+}
+
+// testServe serves ws on nl.
+func testServe(nl net.Listener, ws *workflowServer) net.Listener {
+	l := &testListener{Listener: nl}
+
 	go func() {
 		for {
-			conn, err := l.Accept()
+			conn, err := nl.Accept()
 			if err != nil {
 				return
 			}
 
-			go func() {
-				wfsc := workflow.Workflow_ServerToClient(ws)
-
-				connection := rpc.NewConn(
-					rpc.StreamTransport(conn),
-					rpc.MainInterface(wfsc.Client),
-				)
+			l.m.Lock()
+			l.conns = append(l.conns, conn)
+			l.m.Unlock()
 
-				// Wait for connection to abort.
-				<-connection.Done()
-			}()
+			go testServeConn(conn, ws)
 		}
 	}()
 
-	return l, nil
+	return l
+}
+
+// testServeConn serves ws on conn until the connection is aborted.
+func testServeConn(conn net.Conn, ws *workflowServer) {
+	wfsc := workflow.Workflow_ServerToClient(ws)
+
+	connection := rpc.NewConn(
+		rpc.StreamTransport(conn),
+		rpc.MainInterface(wfsc.Client),
+	)
+
+	// Wait for connection to abort.
+	<-connection.Done()
+}
+
+func TestReconnect(t *testing.T) {
+	var counter int32
+
+	ws := &workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			atomic.AddInt32(&counter, 1)
+			return nil
+		},
+	}
+
+	srvr1, err := testServer(ws)
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr1.Close()
+
+	srvr2, err := testServer(ws)
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr2.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s,capnproto://%s", srvr1.Addr().String(), srvr2.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.WithMaxRetries(backoff.NewConstantBackOff(10*time.Millisecond), 10)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	if _, err := w.Produce(context.Background(), TestProduceMessage); err != nil {
+		t.Fatalf("Could not produce message: %s", err.Error())
+	}
+
+	// take down the first server, the worker should fail over to the second.
+	srvr1.Close()
+
+	if _, err := w.Produce(context.Background(), TestProduceMessage); err != nil {
+		t.Fatalf("Could not produce message after reconnect: %s", err.Error())
+	}
+
+	if n := atomic.LoadInt32(&counter); n != 2 {
+		t.Fatalf("expected 2 produced messages, got %d", n)
+	}
+}
+
+func TestReconnectContext(t *testing.T) {
+	w, err := New(
+		MustWithRavenURL("capnproto://raven.internal:8023"),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithLazyConnect(),
+		WithBackOff(StopBackOff),
+		WithDialer(func(ctx context.Context, u *url.URL) (net.Conn, error) {
+			// a server that doesn't answer.
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+				return nil, errors.New("dial timeout")
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	var wg sync.WaitGroup
+
+	// the second caller waits for the first one dialing.
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+
+			if _, err := w.Produce(ctx, TestProduceMessage); err == nil {
+				t.Errorf("Expected an error producing without a server")
+			} else if d := time.Since(start); d > time.Second {
+				t.Errorf("Expected Produce to return after the deadline, took %s", d)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestClose(t *testing.T) {
+	srvr, err := testServer(&workflowServer{
+		getJob: func(getJob workflow.Connection_getJob) error {
+			return errors.New("item not found")
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		MustWithConsumeTimeout("0s"),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.NewConstantBackOff(10 * time.Millisecond)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	transport := w.(*DefaultWorker).transport
+
+	// waiting for work should stop when the worker is closed.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		w.Close()
+	}()
+
+	if _, err := w.Consume(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed from Consume, got %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Expected second Close to succeed, got %s", err)
+	}
+
+	if !transport.isBroken() {
+		t.Fatalf("Expected transport to be closed")
+	}
+
+	ref := Reference{AckID: workerID.String(), EventID: flowID.String()}
+
+	if _, err := w.Get(context.Background(), ref); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed from Get, got %v", err)
+	}
+
+	if err := w.Ack(context.Background(), ref); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed from Ack, got %v", err)
+	}
+
+	if _, err := w.Produce(context.Background(), TestProduceMessage); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed from Produce, got %v", err)
+	}
+
+	if _, err := w.ProduceAsync(context.Background(), TestProduceMessage); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed from ProduceAsync, got %v", err)
+	}
+
+	if err := w.Flush(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed from Flush, got %v", err)
+	}
+}
+
+// authServer only accepts workers with token.
+func authServer(token string, produced *int32) *workflowServer {
+	return &workflowServer{
+		connect: func(connect workflow.Workflow_connect) error {
+			if v, err := connect.Params.Token(); err != nil {
+				return err
+			} else if v != token {
+				return errors.New("unauthorized")
+			}
+
+			return nil
+		},
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			atomic.AddInt32(produced, 1)
+			return nil
+		},
+	}
+}
+
+func TestCustomEnvironment(t *testing.T) {
+	srvr, err := testServer(&workflowServer{
+		putNewEvent: func(putNewEvent workflow.Connection_putNewEvent) error {
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	// without a logger, the default logger is used.
+	w, err := New(CustomEnvironment(srvr.Addr().String(), flowID.String(), workerID.String()))
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	if _, err := w.Produce(context.Background(), TestProduceMessage); err != nil {
+		t.Fatalf("Could not produce message: %s", err.Error())
+	}
+}
+
+func TestAuthToken(t *testing.T) {
+	var produced int32
+
+	srvr, err := testServer(authServer("secret", &produced))
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithAuthToken("secret"),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	if err := w.WaitReady(context.Background()); err != nil {
+		t.Fatalf("Expected worker to be accepted, got %s", err)
+	}
+
+	if _, err := w.Produce(context.Background(), TestProduceMessage); err != nil {
+		t.Fatalf("Could not produce message: %s", err.Error())
+	}
+
+	if n := atomic.LoadInt32(&produced); n != 1 {
+		t.Fatalf("Expected 1 produced message, got %d", n)
+	}
+}
+
+func TestAuthTokenRejected(t *testing.T) {
+	var produced int32
+
+	srvr, err := testServer(authServer("secret", &produced))
+	if err != nil {
+		t.Fatalf("Could not start test server: %s", err.Error())
+	}
+
+	defer srvr.Close()
+
+	w, err := New(
+		MustWithRavenURL(fmt.Sprintf("capnproto://%s", srvr.Addr().String())),
+		MustWithFlowID(flowID.String()),
+		MustWithWorkerID(workerID.String()),
+		MustWithLogger(DefaultLogger),
+		WithAuthToken("wrong"),
+		WithBackOff(func() backoff.BackOff {
+			return backoff.NewConstantBackOff(time.Second)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Could not initialize new raven worker: %s", err.Error())
+	}
+
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	// a rejected worker should not be retried.
+	if err := w.WaitReady(ctx); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Expected ErrUnauthorized from WaitReady, got %v", err)
+	}
+
+	if _, err := w.Produce(ctx, TestProduceMessage); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Expected ErrUnauthorized from Produce, got %v", err)
+	}
+
+	if n := atomic.LoadInt32(&produced); n != 0 {
+		t.Fatalf("Expected no produced messages, got %d", n)
+	}
 }
@@ -389,6 +389,46 @@ func (c Connection) AckJob(ctx context.Context, params func(Connection_ackJob_Pa
 	}
 	return Connection_ackJob_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
 }
+func (c Connection) ExtendJob(ctx context.Context, params func(Connection_extendJob_Params) error, opts ...capnp.CallOption) Connection_extendJob_Results_Promise {
+	if c.Client == nil {
+		return Connection_extendJob_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
+	}
+	call := &capnp.Call{
+		Ctx: ctx,
+		Method: capnp.Method{
+			InterfaceID:   0xfdce09f9d8aeb8ae,
+			MethodID:      5,
+			InterfaceName: "job.capnp:Connection",
+			MethodName:    "extendJob",
+		},
+		Options: capnp.NewCallOptions(opts),
+	}
+	if params != nil {
+		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		call.ParamsFunc = func(s capnp.Struct) error { return params(Connection_extendJob_Params{Struct: s}) }
+	}
+	return Connection_extendJob_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+}
+func (c Connection) NackJob(ctx context.Context, params func(Connection_nackJob_Params) error, opts ...capnp.CallOption) Connection_nackJob_Results_Promise {
+	if c.Client == nil {
+		return Connection_nackJob_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
+	}
+	call := &capnp.Call{
+		Ctx: ctx,
+		Method: capnp.Method{
+			InterfaceID:   0xfdce09f9d8aeb8ae,
+			MethodID:      6,
+			InterfaceName: "job.capnp:Connection",
+			MethodName:    "nackJob",
+		},
+		Options: capnp.NewCallOptions(opts),
+	}
+	if params != nil {
+		call.ParamsSize = capnp.ObjectSize{DataSize: 16, PointerCount: 2}
+		call.ParamsFunc = func(s capnp.Struct) error { return params(Connection_nackJob_Params{Struct: s}) }
+	}
+	return Connection_nackJob_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+}
 
 type Connection_Server interface {
 	PutEvent(Connection_putEvent) error
@@ -400,6 +440,10 @@ type Connection_Server interface {
 	GetJob(Connection_getJob) error
 
 	AckJob(Connection_ackJob) error
+
+	ExtendJob(Connection_extendJob) error
+
+	NackJob(Connection_nackJob) error
 }
 
 func Connection_ServerToClient(s Connection_Server) Connection {
@@ -409,7 +453,7 @@ func Connection_ServerToClient(s Connection_Server) Connection {
 
 func Connection_Methods(methods []server.Method, s Connection_Server) []server.Method {
 	if cap(methods) == 0 {
-		methods = make([]server.Method, 0, 5)
+		methods = make([]server.Method, 0, 7)
 	}
 
 	methods = append(methods, server.Method{
@@ -465,7 +509,7 @@ func Connection_Methods(methods []server.Method, s Connection_Server) []server.M
 			call := Connection_getJob{c, opts, Connection_getJob_Params{Struct: p}, Connection_getJob_Results{Struct: r}}
 			return s.GetJob(call)
 		},
-		ResultsSize: capnp.ObjectSize{DataSize: 0, PointerCount: 2},
+		ResultsSize: capnp.ObjectSize{DataSize: 8, PointerCount: 2},
 	})
 
 	methods = append(methods, server.Method{
@@ -482,6 +526,34 @@ func Connection_Methods(methods []server.Method, s Connection_Server) []server.M
 		ResultsSize: capnp.ObjectSize{DataSize: 8, PointerCount: 0},
 	})
 
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xfdce09f9d8aeb8ae,
+			MethodID:      5,
+			InterfaceName: "job.capnp:Connection",
+			MethodName:    "extendJob",
+		},
+		Impl: func(c context.Context, opts capnp.CallOptions, p, r capnp.Struct) error {
+			call := Connection_extendJob{c, opts, Connection_extendJob_Params{Struct: p}, Connection_extendJob_Results{Struct: r}}
+			return s.ExtendJob(call)
+		},
+		ResultsSize: capnp.ObjectSize{DataSize: 16, PointerCount: 0},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xfdce09f9d8aeb8ae,
+			MethodID:      6,
+			InterfaceName: "job.capnp:Connection",
+			MethodName:    "nackJob",
+		},
+		Impl: func(c context.Context, opts capnp.CallOptions, p, r capnp.Struct) error {
+			call := Connection_nackJob{c, opts, Connection_nackJob_Params{Struct: p}, Connection_nackJob_Results{Struct: r}}
+			return s.NackJob(call)
+		},
+		ResultsSize: capnp.ObjectSize{DataSize: 8, PointerCount: 0},
+	})
+
 	return methods
 }
 
@@ -525,6 +597,22 @@ type Connection_ackJob struct {
 	Results Connection_ackJob_Results
 }
 
+// Connection_extendJob holds the arguments for a server call to Connection.extendJob.
+type Connection_extendJob struct {
+	Ctx     context.Context
+	Options capnp.CallOptions
+	Params  Connection_extendJob_Params
+	Results Connection_extendJob_Results
+}
+
+// Connection_nackJob holds the arguments for a server call to Connection.nackJob.
+type Connection_nackJob struct {
+	Ctx     context.Context
+	Options capnp.CallOptions
+	Params  Connection_nackJob_Params
+	Results Connection_nackJob_Results
+}
+
 type Connection_putEvent_Params struct{ capnp.Struct }
 
 // Connection_putEvent_Params_TypeID is the unique identifier for the type Connection_putEvent_Params.
@@ -1045,12 +1133,12 @@ type Connection_getJob_Results struct{ capnp.Struct }
 const Connection_getJob_Results_TypeID = 0xafa27e7eec8d315d
 
 func NewConnection_getJob_Results(s *capnp.Segment) (Connection_getJob_Results, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 2})
 	return Connection_getJob_Results{st}, err
 }
 
 func NewRootConnection_getJob_Results(s *capnp.Segment) (Connection_getJob_Results, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 2})
 	return Connection_getJob_Results{st}, err
 }
 
@@ -1092,12 +1180,20 @@ func (s Connection_getJob_Results) SetAckID(v []byte) error {
 	return s.Struct.SetData(1, v)
 }
 
+func (s Connection_getJob_Results) Deliveries() uint32 {
+	return s.Struct.Uint32(0)
+}
+
+func (s Connection_getJob_Results) SetDeliveries(v uint32) {
+	s.Struct.SetUint32(0, v)
+}
+
 // Connection_getJob_Results_List is a list of Connection_getJob_Results.
 type Connection_getJob_Results_List struct{ capnp.List }
 
 // NewConnection_getJob_Results creates a new list of Connection_getJob_Results.
 func NewConnection_getJob_Results_List(s *capnp.Segment, sz int32) (Connection_getJob_Results_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2}, sz)
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 2}, sz)
 	return Connection_getJob_Results_List{l}, err
 }
 
@@ -1283,6 +1379,313 @@ func (p Connection_ackJob_Results_Promise) Struct() (Connection_ackJob_Results,
 	return Connection_ackJob_Results{s}, err
 }
 
+type Connection_extendJob_Params struct{ capnp.Struct }
+
+// Connection_extendJob_Params_TypeID is the unique identifier for the type Connection_extendJob_Params.
+const Connection_extendJob_Params_TypeID = 0xb60293c655db728f
+
+func NewConnection_extendJob_Params(s *capnp.Segment) (Connection_extendJob_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Connection_extendJob_Params{st}, err
+}
+
+func NewRootConnection_extendJob_Params(s *capnp.Segment) (Connection_extendJob_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Connection_extendJob_Params{st}, err
+}
+
+func ReadRootConnection_extendJob_Params(msg *capnp.Message) (Connection_extendJob_Params, error) {
+	root, err := msg.RootPtr()
+	return Connection_extendJob_Params{root.Struct()}, err
+}
+
+func (s Connection_extendJob_Params) String() string {
+	str, _ := text.Marshal(0xb60293c655db728f, s.Struct)
+	return str
+}
+
+func (s Connection_extendJob_Params) AckID() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return []byte(p.Data()), err
+}
+
+func (s Connection_extendJob_Params) HasAckID() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s Connection_extendJob_Params) SetAckID(v []byte) error {
+	return s.Struct.SetData(0, v)
+}
+
+// Connection_extendJob_Params_List is a list of Connection_extendJob_Params.
+type Connection_extendJob_Params_List struct{ capnp.List }
+
+// NewConnection_extendJob_Params creates a new list of Connection_extendJob_Params.
+func NewConnection_extendJob_Params_List(s *capnp.Segment, sz int32) (Connection_extendJob_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return Connection_extendJob_Params_List{l}, err
+}
+
+func (s Connection_extendJob_Params_List) At(i int) Connection_extendJob_Params {
+	return Connection_extendJob_Params{s.List.Struct(i)}
+}
+
+func (s Connection_extendJob_Params_List) Set(i int, v Connection_extendJob_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s Connection_extendJob_Params_List) String() string {
+	str, _ := text.MarshalList(0xb60293c655db728f, s.List)
+	return str
+}
+
+// Connection_extendJob_Params_Promise is a wrapper for a Connection_extendJob_Params promised by a client call.
+type Connection_extendJob_Params_Promise struct{ *capnp.Pipeline }
+
+func (p Connection_extendJob_Params_Promise) Struct() (Connection_extendJob_Params, error) {
+	s, err := p.Pipeline.Struct()
+	return Connection_extendJob_Params{s}, err
+}
+
+type Connection_extendJob_Results struct{ capnp.Struct }
+
+// Connection_extendJob_Results_TypeID is the unique identifier for the type Connection_extendJob_Results.
+const Connection_extendJob_Results_TypeID = 0xde6ec4dcc6c4dad0
+
+func NewConnection_extendJob_Results(s *capnp.Segment) (Connection_extendJob_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 0})
+	return Connection_extendJob_Results{st}, err
+}
+
+func NewRootConnection_extendJob_Results(s *capnp.Segment) (Connection_extendJob_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 0})
+	return Connection_extendJob_Results{st}, err
+}
+
+func ReadRootConnection_extendJob_Results(msg *capnp.Message) (Connection_extendJob_Results, error) {
+	root, err := msg.RootPtr()
+	return Connection_extendJob_Results{root.Struct()}, err
+}
+
+func (s Connection_extendJob_Results) String() string {
+	str, _ := text.Marshal(0xde6ec4dcc6c4dad0, s.Struct)
+	return str
+}
+
+func (s Connection_extendJob_Results) Extended() bool {
+	return s.Struct.Bit(0)
+}
+
+func (s Connection_extendJob_Results) SetExtended(v bool) {
+	s.Struct.SetBit(0, v)
+}
+
+func (s Connection_extendJob_Results) Lease() uint64 {
+	return s.Struct.Uint64(8)
+}
+
+func (s Connection_extendJob_Results) SetLease(v uint64) {
+	s.Struct.SetUint64(8, v)
+}
+
+// Connection_extendJob_Results_List is a list of Connection_extendJob_Results.
+type Connection_extendJob_Results_List struct{ capnp.List }
+
+// NewConnection_extendJob_Results creates a new list of Connection_extendJob_Results.
+func NewConnection_extendJob_Results_List(s *capnp.Segment, sz int32) (Connection_extendJob_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 16, PointerCount: 0}, sz)
+	return Connection_extendJob_Results_List{l}, err
+}
+
+func (s Connection_extendJob_Results_List) At(i int) Connection_extendJob_Results {
+	return Connection_extendJob_Results{s.List.Struct(i)}
+}
+
+func (s Connection_extendJob_Results_List) Set(i int, v Connection_extendJob_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s Connection_extendJob_Results_List) String() string {
+	str, _ := text.MarshalList(0xde6ec4dcc6c4dad0, s.List)
+	return str
+}
+
+// Connection_extendJob_Results_Promise is a wrapper for a Connection_extendJob_Results promised by a client call.
+type Connection_extendJob_Results_Promise struct{ *capnp.Pipeline }
+
+func (p Connection_extendJob_Results_Promise) Struct() (Connection_extendJob_Results, error) {
+	s, err := p.Pipeline.Struct()
+	return Connection_extendJob_Results{s}, err
+}
+
+type Connection_nackJob_Params struct{ capnp.Struct }
+
+// Connection_nackJob_Params_TypeID is the unique identifier for the type Connection_nackJob_Params.
+const Connection_nackJob_Params_TypeID = 0xc9b5eef6f172d830
+
+func NewConnection_nackJob_Params(s *capnp.Segment) (Connection_nackJob_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 2})
+	return Connection_nackJob_Params{st}, err
+}
+
+func NewRootConnection_nackJob_Params(s *capnp.Segment) (Connection_nackJob_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 2})
+	return Connection_nackJob_Params{st}, err
+}
+
+func ReadRootConnection_nackJob_Params(msg *capnp.Message) (Connection_nackJob_Params, error) {
+	root, err := msg.RootPtr()
+	return Connection_nackJob_Params{root.Struct()}, err
+}
+
+func (s Connection_nackJob_Params) String() string {
+	str, _ := text.Marshal(0xc9b5eef6f172d830, s.Struct)
+	return str
+}
+
+func (s Connection_nackJob_Params) AckID() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return []byte(p.Data()), err
+}
+
+func (s Connection_nackJob_Params) HasAckID() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s Connection_nackJob_Params) SetAckID(v []byte) error {
+	return s.Struct.SetData(0, v)
+}
+
+func (s Connection_nackJob_Params) Requeue() bool {
+	return s.Struct.Bit(0)
+}
+
+func (s Connection_nackJob_Params) SetRequeue(v bool) {
+	s.Struct.SetBit(0, v)
+}
+
+func (s Connection_nackJob_Params) Delay() uint64 {
+	return s.Struct.Uint64(8)
+}
+
+func (s Connection_nackJob_Params) SetDelay(v uint64) {
+	s.Struct.SetUint64(8, v)
+}
+
+func (s Connection_nackJob_Params) Reason() (string, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.Text(), err
+}
+
+func (s Connection_nackJob_Params) HasReason() bool {
+	p, err := s.Struct.Ptr(1)
+	return p.IsValid() || err != nil
+}
+
+func (s Connection_nackJob_Params) ReasonBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.TextBytes(), err
+}
+
+func (s Connection_nackJob_Params) SetReason(v string) error {
+	return s.Struct.SetText(1, v)
+}
+
+// Connection_nackJob_Params_List is a list of Connection_nackJob_Params.
+type Connection_nackJob_Params_List struct{ capnp.List }
+
+// NewConnection_nackJob_Params creates a new list of Connection_nackJob_Params.
+func NewConnection_nackJob_Params_List(s *capnp.Segment, sz int32) (Connection_nackJob_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 16, PointerCount: 2}, sz)
+	return Connection_nackJob_Params_List{l}, err
+}
+
+func (s Connection_nackJob_Params_List) At(i int) Connection_nackJob_Params {
+	return Connection_nackJob_Params{s.List.Struct(i)}
+}
+
+func (s Connection_nackJob_Params_List) Set(i int, v Connection_nackJob_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s Connection_nackJob_Params_List) String() string {
+	str, _ := text.MarshalList(0xc9b5eef6f172d830, s.List)
+	return str
+}
+
+// Connection_nackJob_Params_Promise is a wrapper for a Connection_nackJob_Params promised by a client call.
+type Connection_nackJob_Params_Promise struct{ *capnp.Pipeline }
+
+func (p Connection_nackJob_Params_Promise) Struct() (Connection_nackJob_Params, error) {
+	s, err := p.Pipeline.Struct()
+	return Connection_nackJob_Params{s}, err
+}
+
+type Connection_nackJob_Results struct{ capnp.Struct }
+
+// Connection_nackJob_Results_TypeID is the unique identifier for the type Connection_nackJob_Results.
+const Connection_nackJob_Results_TypeID = 0xeb66c5d00073c0ac
+
+func NewConnection_nackJob_Results(s *capnp.Segment) (Connection_nackJob_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return Connection_nackJob_Results{st}, err
+}
+
+func NewRootConnection_nackJob_Results(s *capnp.Segment) (Connection_nackJob_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return Connection_nackJob_Results{st}, err
+}
+
+func ReadRootConnection_nackJob_Results(msg *capnp.Message) (Connection_nackJob_Results, error) {
+	root, err := msg.RootPtr()
+	return Connection_nackJob_Results{root.Struct()}, err
+}
+
+func (s Connection_nackJob_Results) String() string {
+	str, _ := text.Marshal(0xeb66c5d00073c0ac, s.Struct)
+	return str
+}
+
+func (s Connection_nackJob_Results) Nacked() bool {
+	return s.Struct.Bit(0)
+}
+
+func (s Connection_nackJob_Results) SetNacked(v bool) {
+	s.Struct.SetBit(0, v)
+}
+
+// Connection_nackJob_Results_List is a list of Connection_nackJob_Results.
+type Connection_nackJob_Results_List struct{ capnp.List }
+
+// NewConnection_nackJob_Results creates a new list of Connection_nackJob_Results.
+func NewConnection_nackJob_Results_List(s *capnp.Segment, sz int32) (Connection_nackJob_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0}, sz)
+	return Connection_nackJob_Results_List{l}, err
+}
+
+func (s Connection_nackJob_Results_List) At(i int) Connection_nackJob_Results {
+	return Connection_nackJob_Results{s.List.Struct(i)}
+}
+
+func (s Connection_nackJob_Results_List) Set(i int, v Connection_nackJob_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s Connection_nackJob_Results_List) String() string {
+	str, _ := text.MarshalList(0xeb66c5d00073c0ac, s.List)
+	return str
+}
+
+// Connection_nackJob_Results_Promise is a wrapper for a Connection_nackJob_Results promised by a client call.
+type Connection_nackJob_Results_Promise struct{ *capnp.Pipeline }
+
+func (p Connection_nackJob_Results_Promise) Struct() (Connection_nackJob_Results, error) {
+	s, err := p.Pipeline.Struct()
+	return Connection_nackJob_Results{s}, err
+}
+
 type Workflow struct{ Client capnp.Client }
 
 // Workflow_TypeID is the unique identifier for the type Workflow.
@@ -1303,7 +1706,7 @@ func (c Workflow) Connect(ctx context.Context, params func(Workflow_connect_Para
 		Options: capnp.NewCallOptions(opts),
 	}
 	if params != nil {
-		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 2}
+		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 3}
 		call.ParamsFunc = func(s capnp.Struct) error { return params(Workflow_connect_Params{Struct: s}) }
 	}
 	return Workflow_connect_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
@@ -1530,12 +1933,12 @@ type Workflow_connect_Params struct{ capnp.Struct }
 const Workflow_connect_Params_TypeID = 0xfb7429c9d23d519b
 
 func NewWorkflow_connect_Params(s *capnp.Segment) (Workflow_connect_Params, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 3})
 	return Workflow_connect_Params{st}, err
 }
 
 func NewRootWorkflow_connect_Params(s *capnp.Segment) (Workflow_connect_Params, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 3})
 	return Workflow_connect_Params{st}, err
 }
 
@@ -1577,12 +1980,31 @@ func (s Workflow_connect_Params) SetWorkerID(v []byte) error {
 	return s.Struct.SetData(1, v)
 }
 
+func (s Workflow_connect_Params) Token() (string, error) {
+	p, err := s.Struct.Ptr(2)
+	return p.Text(), err
+}
+
+func (s Workflow_connect_Params) HasToken() bool {
+	p, err := s.Struct.Ptr(2)
+	return p.IsValid() || err != nil
+}
+
+func (s Workflow_connect_Params) TokenBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(2)
+	return p.TextBytes(), err
+}
+
+func (s Workflow_connect_Params) SetToken(v string) error {
+	return s.Struct.SetText(2, v)
+}
+
 // Workflow_connect_Params_List is a list of Workflow_connect_Params.
 type Workflow_connect_Params_List struct{ capnp.List }
 
 // NewWorkflow_connect_Params creates a new list of Workflow_connect_Params.
 func NewWorkflow_connect_Params_List(s *capnp.Segment, sz int32) (Workflow_connect_Params_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2}, sz)
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 3}, sz)
 	return Workflow_connect_Params_List{l}, err
 }
 
@@ -2260,97 +2682,116 @@ func (p Workflow_getLatestEventID_Results_Promise) Struct() (Workflow_getLatestE
 	return Workflow_getLatestEventID_Results{s}, err
 }
 
-const schema_d598217bc368711c = "x\xda\xa4Wol\x14\xd5\x16?\xe7\xce\xcc\xce\xce\xb6" +
-	"\xfb\xda\xcb\x14x\x8f\xbc\xa4i\xb3\xe4Q\xd2\xf6\xb5\x05" +
-	"\x0cn\xc4\x85\x8a\x90-`vj\xc0\x88b\x9c\xb6#" +
-	",\xdd\xee\x96\x9dYj1\x05!@,\x7f\x94\x92\x90" +
-	"\xf0'&\x8a\x91\x04\x12\xc1\xe8\x07\x8cbTHI " +
-	"\xfc\x89$\x18\x12#\xf1\x83DDcB\"&\x86\xd4" +
-	"1\xf7\xce\xce\xec\xec\xb2m\x05\xbf\xed\xce\x9c\xf3\xbb\xe7" +
-	"\x9c\xdf9\xbfs\xa7\xa5R\\HZ%[\x01\xd0," +
-	")`\x8f}\x1c:~~\xe6\xb2\xad@U\x04\x10e" +
-	"\x809\xb7\xc4z\x04\xd1\x0e\xf5\xbd\xb0\xb9\xee\xd3\xb7v" +
-	"\x00\x9d\x8a\x00\x12\xb2W\x97\xc5\xd9\x08\xa8^\x17c\x80" +
-	"\xf6\xb2\xb3\xa3b|W\xfc\x0d\xc7\x80\xbb\xde\x13\xdb\x98" +
-	"\xeb\xe8\xd8\xd1/\x0e\xffyq\xb7\x03\xea\xb8~'\xce" +
-	"`\xae\xb7\xb8\xeb\xd5\xdf\xae\xee{\xa9\xf2\x87\xdd\xa0\x85" +
-	"\x10\xed\xffnXw\xee\xb5\xba\x83\xd7\x1dKU\x91\xb6" +
-	"\xa9a\xc9\xf95\x00h\xff\xff\xdc\xac\xc6_+\xa7\xed" +
-	"\xf1\xa3\xf5IY\x866(1\xb4\xed\x81\x9d\xff\xfbl" +
-	"\xf8\xe6^\xbf\xc1!i53x\x9f\x1b\xdc\xdf}\xf0" +
-	"\xc4\xb5\xe4\xcf\xfb\xfc\x06\xa3\x12O\xe527\xd8\x7f," +
-	"<\xb0\xf4l\xe4 hS\xd1\xcdeL\xaag\x06R" +
-	"\x80\x19\x9c\xba\xf2A\xcb\x9c\xff\xacy\x0fhH(\xc4" +
-	"\x0b\xa8\xce\x0c\x1cV\x9b\x02\xcc\xbe!\xb0\x14UE\x96" +
-	"\x01\xec\xe0\x8d\xc1-_Gw\x1e\xf3\x9fw7\xb0\x89" +
-	"\xc1\x8dq\xb85\xad{\x7f\xd9\xbc\xf9\xe8\xa9|m\x09" +
-	"3h\x92\xf9y\xf3d\x96s\xe3\xf0\xf4\xd6\xcc\xd4\xfa" +
-	"\x8f\xfc\x08#r\x173x[f\x08g\xe65\xed\x99" +
-	"vd\xff\x19?\xc2\xe72/\xf1(G\xb85S\xb1" +
-	"\xbel^w\xde\x8fP\x17\xe4G4\x04\x19\xc2\x90\x19" +
-	"V\x9e\xfc\xf6\xea\x05?\xbf\xf1`\x073X\xc9\x0d\xb6" +
-	"v/\xefy\xb3\xe2\xf9K~\x84\\\x90\x1f1\xc4\x0d" +
-	"\x9aOg\xc3\x97\x8eW\xdf\xf4\xc7\xf0n\x90\x97\xf5x" +
-	"\x90\xc5@\x94\xbb\xf7\x0e\xddY~\xdb\x7f\x84\xa4\xb41" +
-	"\x83\xb0\xc2\x10\xdaO7|\x13\x9a\xff\xceO@\xab\xd1" +
-	"\xfe\xea\xc6\x9c\x0f\xdbWm\xba\xe7 \xa9M\xcaE\xf5" +
-	"q\x85\xfd\x9a\xa70\xb0\xdbuK\xbe?V\xb7\xfe\x8e" +
-	"\xaf\xddF\x94\x19\xac\xdd<\xc7\x92n\x12\x98\xf3\xa0\xb2" +
-	"M\x1dR\xa6\x03\xa8\xc3\xca\x8f\x80\xf6\x81\xaa)K\x1e" +
-	"\x9b{\xf1w\x7fL}\xa1v\x16S.\xc4b:\xa2" +
-	"-\xb8v\xa1\xc1\xba\x9fO\x9bgu 4\x85\x97>" +
-	"\xc4\x029\xf9\xc9\xc9\x1b\x7f(W\xc6\x1e\xe8\x85\xb1\xd0" +
-	"\x09U\xaa`\xf6X\xb1\x14\xd5\xeb\x1524\xda\xeb3" +
-	"]\xcd\xddz\x7fZ\xe8\x8f>\x97\xc9\xf6\xbe\x92\xca\x0c" +
-	"4\xaf5,-g\xe4\x0c3\x92\xd0\xb3\xb2\xdeg\xfa" +
-	"\xad\x9e\xca\xa4\xd3F\xb7\x95\xcc\xa4\x99\xdd\xd3\x1b\x8d\xb4" +
-	"\x15I\xe8UY\xbd\xcf\xd4DA\x04\x10\x11\x80\x86\xdb" +
-	"\x01\xb4\xa0\x80Z\x0d\xc1-\x063\x8a/\xc60\x10\x0c" +
-	"\x03\x8e\x83\xd6\x9f\xcb\xa3u\x1a\xb5f.e\x99ec" +
-	"\xebv\x1c\"\x9d\x86\x99K\x09V\xd1\x99\xab\x01\xb4J" +
-	"\x01\xb5\x7f\x13\xb4\xf3vI\x102i\xa4\x85\xa2\x00\"" +
-	"\xf5\x85\x80\xfdQ\x9e*$\x10\xb5\xa0\x07\xd5\xd0\x01\xa0" +
-	"\xcd\x12P\x9bK\x10\xb1\x86\x0d\x1dm\xed\x04\xd0Z\x04" +
-	"\xd4\x9e h\x0fd\xb2\xbdF6\xde\x03\x00^Z\x1b" +
-	"\x18\xd0\xb3\xc9M\x80\x06*@P\xf1\x9d#\x16\x97\x97" +
-	"'\xba(\x95Zed\xcdd&mF\x12\xb5\xfa\xa3" +
-	"T\xb0\x04v\xb9n\x19\xa6\x03\x1e_\xcc\xc8\xd3\x85b" +
-	"\xcch\x013\xc6\x9c\xca\x93R\xa6\x11:\x0d\xb3\x8aq" +
-	"R\x0e,B0\xc6s7\xf1_\x80\x09\x01\xb1\xba\xa0" +
-	"\xa0\x80\xec\xe18\x94\xeb\xdd\xbd\x1d\x99.\xce\xa5\\\x02" +
-	"\xdeV\x88\xb4V\xef\xee5z\x10\x81 \x16S\xe7\x04" +
-	"*d\x06\x18{5\x82\x84\xa27\x1e\xe8\x8a<\x1di" +
-	"\x07Bw0\xdds\x95\x1a]\x01\xa4\x83G\x81\xd0\x9c" +
-	"\x8c\x055AW\x98h\xb2\x03\x08\xd5e$\xde\x12B" +
-	"W\xa8\xe9\xcaN t\x85\x8c\x82'\xee\xe8J\"]" +
-	"\xb4\x0b\x08] o\xc9w\xe0B\xb4]\xc2\xd1e\\" +
-	"\xce\xa4M\xe7\xb9\xd3|\x00\xfe\x7f\x98\x7f\xc7\xd9D\x97" +
-	"Nf\x93\xc0\xbf\xdfQ\x9d1\xc3\x9c\x881\xdeR>" +
-	"\xc6<\x95\x9a\x90\xb1\xb5\x86U\xc4\x98odX\xbfF" +
-	"\x04\xd4Z\x08Rwf\x9a\xda\x0asT\xda\xc4\x8c\xd6" +
-	"\x87m\xe9r\x9d\xf2\xf0J\x93o;w>|9\xb4" +
-	"M\x92C-?\xa0\xa4Z\xd5\xe3\xe6Rn\x96&\xed" +
-	"v>J%#T]\\\xa0b\xd5|\xc6\x18p\x85" +
-	"\x933\x0e\xf0\x08\xe5y0\xce2\xf2\xd1\xe1\x13\xd8\xbc" +
-	"\x02.\xf6+\xe0$\xc2\xee\xae\x89\x7f\xd24\xe3\x100" +
-	"\xd9\x86rw\xcaxE\x9f\x0c\x96\xf4G9P\xf3\x0a" +
-	"\xc3\xd2{tK\x87\xd2\x8dQ?I&r\xaf1\x88" +
-	"\x95@\xb0\x12\xb0v\xa3\x9e\xca\x19\xee\xbf\x89G-O" +
-	"\x83_\xf6x$\xa0\x89\xe8\xbf\xa1`\x87]\x88\x0d\xb4" +
-	"j/2\x9dM\xfd\x8b\x02j\xeb\x0a\xbb\xcc`u\x7f" +
-	"Y@-E\x90\x12\xacA\x02@\x93\xb3\x01\xb4\x1e\x01" +
-	"\xb5\xd7\x09R\x81\xd4\xa0\x00@\x87\x98\xf7\xab\x02j\xdb" +
-	"\xd9\xcaH\xa6,#\xeb*1S8\x8b\x95-\xcfM" +
-	"U\x9fa\xe9\x05A\xf1Bs\x04%\xe64L\xb9y" +
-	"\x1f\xa7\x9d\x13|%\xc2\xa3RV\xee\xd6\xc0!\xb1\xa8" +
-	"\x03\xa3\xe5xc\x8d\xde(\xa06\xff\xc1=9Q\xdf" +
-	"\xa3\x9bK\x15K&\xbf\x94\x00\xbc\x9b(\xba\xdf$t" +
-	"\x84-\x97a\xb6x\xdc\x0b\x1f\xba\x17^:\xd4\xe5," +
-	"%\xe2}\xe3\xa0{S\xf5\x96\x92\xe0\xdd7\xd1\xbd\xac" +
-	"\xd3\x95Q 4.\xa3\xe8\xdd\xbe\xd1\xfdp\xa0\x0b\xd8" +
-	"\xbbV\xd9vg\xd1Y9n\xb5A6\xd2\xfeE\xc5" +
-	"\xdf\xc6\x9c\x06\\\x881G/\xf9\xfa\xf9+\x00\x00\xff" +
-	"\xff\xa8<\x8do"
+const schema_d598217bc368711c = "x\xda\x9cW\x7fl\x1c\xc5\x15~o\xf6\xd6{?c" +
+	"\x0f\xe7@AT\x96#\xa3b\x14\\\xdb!\x15\x9c\x9a" +
+	"\x9e\xe3\x06\xa8\x8dS\xdd\xb8\x0d\xd4\xb4\xa9\xba\xb6\x07r" +
+	"\xf8|\xe7\xdc\xee\xc5q\xaa\xc4\x05\x01j\x08\x14J\x15" +
+	")\x80*\xb5\xa9\x1a)\x88\x92\xaaUJ\x7f\x80\x9a\x02" +
+	"N\x95\x08\x11\x82\xd4*\x82\x12\xf5\x8fF\xa5\xd0\"E" +
+	"*TQ\x94n\xf5fw\xee\xf6\xceg;\xcd\x7f\xf6" +
+	"\xed\x9bo\xde\xcc\xf7\xbd\xf7\xcd\xeb}'2\xc0\xfa\xcc" +
+	"{\xe2\x00b\xa7\xd9\xe2]\xfae\xfc\xf0\xf1\x1b\xeez" +
+	"\x10x\x1a\x01\"\x16\xc0:n\xaeA\x88x\xf1\xe9\xaf" +
+	"\xef\xe9\xfc\xed\x93\x8f\x00_\x8d\x00&\xd2\xa7\x0b\x91\x9b" +
+	"\x100\x8df\x16\xd0\xbb\xeb\xd5\x85\xc8\xd0cC\xdf\xf5" +
+	"\x03\xd4\xd2N\xb3\x9f\x96.\\:\xf8\xfbg\xff{r" +
+	"\x9f\x0f\xea/\x8d\x99\xd7\xd1R\xae\x96\x9e\xfa\xf7\xa9\xa7" +
+	"\xbe\x99\xfc\xdb>\x10qD\xef\xfa\xed\xdb^\xfbv\xe7" +
+	"\x81?\xf9\x91\xe9\xf5\xe6C\xe9\xdbL\xff\xafY@\xef" +
+	"\xb3\xaf\xdd\xb8\xf6\xa3\xe4\xd5\x8f\x87\xd1\x9e1\xcb\x84\xf6" +
+	"S\x85\xf6p\xcb\xa3\x9f\xf9\xdd\xde\xb3O\x84\x03\x16\xcc" +
+	"{)\xe0\xb4\x0a\xb8\xb8\xef\xc0\xf3o\xe7?|*\x1c" +
+	"p\xdeTG\xb9\xa0\x02\x9e>\x94\x9a\xbd\xf3\xd5\xae\x03" +
+	" V\xa3>Kw\xcb\x1a\x0a\xe8k\xa1\x80#o\xfe" +
+	"\xacw\xdd\xb5[\x7f\x02<n\xd4\xf2\x05L\x8b\x96g" +
+	"\xd3c-\x14\xbf\xa5\xe5NL\xaf\xb7,\x00/zf" +
+	"n\xfet\xe6\xd1C\xe1\xfd>m\xed\"\xb8n\x8b\xe0" +
+	"\xb6\xf6=\xf1\xcf={\x0e\x1e\x09\xf63\x19E\x8cY" +
+	"jC\xdb:\x02\xe8\xad\xdd{M_i\xf5\x9a_\x84" +
+	"!.Y\xe3\x14\x10\x8b\x12\xc4\x93\xe5w\xb7\x1c\xff\x01" +
+	"\xfb\x15\xf0x5\xa0;\xda\xafRV\x01/\xaf\xbf\xf9" +
+	"\xf1\xab\x9f{\xfa\xe5\x80?\xb5\x85\x88*\x12\xc6\xa2t" +
+	"\xaf\xe7n\x88\xb9\xc7z\xb6\x1d\x0foq4\xaarx" +
+	"E!\xf4\x9e)\x9f\xff\xe4\xa3\xa3'\x88%\xa6!\xde" +
+	"\xf3#\xceE\xff\x0e\xe8\xedvR\xb1/\xbc{\xeaD" +
+	"X#\xbf\x89\x0dS\xc0B\x8c \x1e\x9c\x18\x99\xfc^" +
+	"b\xec\x8d\xf0\x1e\xe7b*\x89\x7f\xa9\x80\x9e\x97\xca\xa9" +
+	"7\x0e\xb7\x9d\x0dg\xc9\xe3\x8a\x9ak\xe3\x94\xe5[\xef" +
+	"\xbc~\xfc/\xaf\x17\xcf\x06I(j*\xf1\x0c\x05\xec" +
+	"V\x01,v\xfe\xe3g>\x18y?\x9c\xc3{qu" +
+	"\x11\xe7\xe2\xb4\xc5\xe0K\xdd\x7f\x8e\xdf\xfa\xa3\x7f\x00o" +
+	"C\xef\x0fg\xd6\xfd|\xf0\xee]\x1f\xfb[\xa5\xcd\xc4" +
+	"\xc94O\xd0_\xa9\x04\x81\xbd\xdfy\xc7_\x0fu>" +
+	"\xf0AH\xd3\xf9\xc4u\x08\x91\xff\xbcp\xccyk\xe1" +
+	"\xbe\x0fI\xb0\xc1\x07\x91Pi\x8e%\xb2\x10\xc2mP" +
+	"\xb4A\xd8s\x89\x87\xd2\xbb\x13\xd7\x00\xa4\xf7&\xe8\xda" +
+	"\xf6\xb7^u\xc7\xe7n9\xf9I8\xe5\xe9\xe4 \xa1" +
+	"U\x92\x84\xf6\x9c\xd8\xf0\xf6\x89n\xf7bpm\x04\xb2" +
+	"n\x7f\xf2*\x0a\xf8a\x92\xe4\xf1\xe2\xaf_<s!" +
+	"\xf6\xe6\xa5Ez\xbc-\xf5|zc\x8a\xe27\xa4\xfe" +
+	"\x88\xe9\xad\xab,Hx\x0f\x94\xc6{&\xec\x99\xa21" +
+	"\x93\xb9\xa7T\x9e\xba\xafP\x9a\xed\xb9_\xba\xa2\"+" +
+	"\xd2\xe9\xca\xd9e\xcb\x9ev\xc2Q_,\x15\x8br\xc2" +
+	"\xcd\x97\x8a\x14w\xfb\x0eYt\xbbrvk\xd9\x9ev" +
+	"D\xc4\x88\x00D\x10\x80\xa7\x06\x01D\xd4@\xd1\xcep" +
+	"^R\xd0\xd0&L\x01\xc3\x14\xe0\x12h3\x95\x00m" +
+	"Tv8\x95\x82\xeb4\xcdm\xc2_\xd05*\x9dJ" +
+	"\xc1p\xeb\xf6\xbc\x17@$\x0d\x14\x9fb\xe8\x05qy" +
+	"0JE\xe4\xb5K\x01D\x1eJ\x01g2\xea\xa8\x90" +
+	"C\x14\xd1*T\xf70\x80\xb8\xd1@q\x0bC\xc4v" +
+	"\xa4\xdf\xfaF\x01D\xaf\x81\xe2\xf3\x0c\xbd\xd9RyJ" +
+	"\x96\x87&\x01\xa0z\xac\xed\x04\xf4\x95\xfc.@\x891" +
+	"`\x18\x0b\xed\x13\xa9\xbf^u\xd0\x8d\x85\xc2\xdd\xb2\xec" +
+	"\xe4KE\xa7+\xd7a_\xc9\x0d6\xc0\x8e\xd8\xaet" +
+	"|\xf0\xa1MD\x9em\xd4cfj\x98YZ\xd4\x9c" +
+	"\x94&B\x18\x95N+q\xd2\x0c\xac\x8baV\x9d\xdd" +
+	"\xc1U\x809\x03\xb1\xad\xd6\xc5\x01qU=z\x88r" +
+	"{bj\xb84\xae\xb8\xb4\x1a\xc0\xfbk\x99v\xd8\x13" +
+	"Sr\x12\x11\x18b=u~\xa2Fi\x96\xd8k7" +
+	"L\x8cT\xcb\x03\xb5\xd1\xf0\xef\x0f\x02\xe3\x8fP\xef\xd5" +
+	"n\x81\xba\x09\xf3\xb9\x83\xc0x\xc5\xc2Z7B\xdd\xfa" +
+	"x~\x18\x18\xb7-dU#Dm\x16|\xcb(0" +
+	"\xbe\xd9B\xa3j0\xa8\xbb2\xdf\xf8\x180\xbe\xc1\x9a" +
+	"\x0f\x148\x80\x9e&\x1c5\xe3V\xa9\xe8\xf8\xbf\xfb\xe2" +
+	"\x03\x08\xff\x87\xc17\xc5&j:)&\x87\x97\xaf\xa8" +
+	"\xd1\xact\x96cLI*\xc4X\xb5K-\xcb\xd8\xfd" +
+	"\xd2\xadc,Y\x05\xbf\x9d\xf4:`\xa0\x18a\xc8u" +
+	"\xcd\x0c\x11\x8d\x9b\x0c\x149\x86\xc8\xda\x91\x01\xf0\xcdT" +
+	"\xa6#\x06\x8a\xaf-\x166Q]\xfb\xcf\x9b\x94\x85\xfc" +
+	"\x0eY\xce\x83!\x1d\x8c\x02\xc3\xe8\xe5j\xbf\x99\xa4\xfe" +
+	"\xff\x96$w\xba\xb28I\x07nR\x9f\x0d\x0a]\x11" +
+	",\x10\xbb\xae\xcaP\xb3!\xa4.\x03Eo\xe8\xe6n" +
+	"\xee\xafu\xa0\x0e\x95m\x03Gm\xb0\xcc\xb6\x8b*x" +
+	"\xc5\x1aS\x05\xdcP\xb8mK\x9e\xa5X;\x0c\xf9\x83" +
+	"h\xab\x82\xda\x04\xfa\x0d\x03\xc5\xb6Z\xe7\x94t\xf3\xdf" +
+	"2P\x14\x18r\x86\xbe\x0c\xf2\x148i\xa0\x98a\xc8" +
+	"\x0dlG\x03\x80O\x93B\xb7\x19(\xdc\xc6K\x9d/" +
+	"K?\xc5\xa0\x0dtL\xca\x82=\xa7\xfbl\xb6,m" +
+	"\xa7T\xc4$0L\xd6k\xa4\xdea\xbe,g\xb5\xc9" +
+	"\xa8\xea\x00\xb8\x02\x85,\xbe\xdd&\xadv8dF\x81" +
+	"[l\x0a\xbb\xc5\x0a&\xa8-5$\x93\xc1\x15d\xb2" +
+	"\xa8\x98\x96\x90\xcd\x8ab\xaf\xb6\x8e\xe6\x86X\xdd\xbc\x8f" +
+	"6_k\xa0\xb8\x95\xa1\xe7/\x97\xca\x115G\x05i" +
+	";\x8b\xbdp\x89G\x84\xb6\xfd\xa5\x14\xba\xd2i\xd8L" +
+	"F\x01\xf5l\x96\xae=i\xbb64\x9a\xfa\x9a\x15." +
+	"\xd0\x9a\x92sZB\x1d;\xecBE.\x12T\xd3n" +
+	"\x18\xb0\xbfB\xad,g\xa1\xe4\xc7\xc5%mN\x1d\x0b" +
+	"D\x04\xc3\x0fV\x1c\xf6j\x07\x85p\x05f.\xb7\x02" +
+	"o\x0a*\xf0;T\x81\xcc\xaf\xc0\xdd\xb4z\xa7\x81\xe2" +
+	"az\"\xe4\x0b\xae,\xeb\x94\xc8\xd1\\\xe2 \xd0W" +
+	"\xeb\xb4t\xed\x9a\x81TS\xf3\x0d$\xeb\x8b\xbe\xd9\x93" +
+	"e\x89\x92\xcc\xa9\x16\x0bW\xca\x7f\xb3W\xa2\x82\xc4:" +
+	"\x9b\xca4\xb3)R\xf7\x97\x0c\x14_\xa5\xeb\x09|J" +
+	"\xf4\xd7|\xaa\xf1\xb1\xd4\xa4\xa0;\xdc\xd2\x94\\\xdc\x81" +
+	"P\x1f\xb7\x95\xceK\x82\xbc\xde0\x01\xaa\xc3\x0d\xeaQ" +
+	"\x99\x9f\xa6\xf7\xc6\x09\x0bk3\x00\xea\x19\x8a\xbf2\x0e" +
+	"\x8c\x1f\xa5\xb7\x88\x1e\xbdQ\xcf6\xfc0\xad\xfb1\xbd" +
+	"E\xf4\x84\x82z\x86\xe4\xfb3\xc0\xf8^\x0b#\xd5\x91" +
+	"\x0f\xf5<KL3\xbe\xddB\xb3:/\xa2\x1e\xa8\xb8" +
+	"\x1c\x05\x03[\xaaS\x1e\xbep\xcc\x01\x1aq\xf8\xe6A" +
+	"0<\xdd\xa4\xfcw\x8b\xa6\x10,Y\x0c\xbfv\xd4\xd7" +
+	"\xac_\"\x03\x98\xf5\xab`@7\x8a\xe1\x12\xe0\xf8|" +
+	"P\x1b9\xc4\xff\x0d\x00\xad\x86@u"
 
 func init() {
 	schemas.Register(schema_d598217bc368711c,
@@ -2367,14 +2808,18 @@ func init() {
 		0xa6863ad17f79d808,
 		0xafa27e7eec8d315d,
 		0xb222156f3117892c,
+		0xb60293c655db728f,
 		0xbc929b168c2d35bc,
 		0xc6682ec0740925e5,
+		0xc9b5eef6f172d830,
 		0xc9cfdb3e090d737d,
 		0xcc590b8e644c6381,
 		0xde10a9cc0d72b72e,
+		0xde6ec4dcc6c4dad0,
 		0xe84cea99f5f10902,
 		0xe9a0380ad629b742,
 		0xea6a21a6e04621e8,
+		0xeb66c5d00073c0ac,
 		0xf57a5642b033d8c1,
 		0xf6ca343646120f95,
 		0xfb7429c9d23d519b,